@@ -0,0 +1,49 @@
+package razcache
+
+import (
+	"time"
+)
+
+// BytesCache is implemented by caches that store values as byte slices without converting them to strings.
+// Caches don't keep the slices passed to SetBytes, and the slices they return belong to the caller.
+// AppendBytes appends the value to dst and returns the extended slice, so buffers can be reused.
+type BytesCache interface {
+	SetBytes(key string, value []byte, ttl time.Duration) error
+	GetBytes(key string) ([]byte, error)
+	AppendBytes(dst []byte, key string) ([]byte, error)
+}
+
+// SetBytes sets a byte slice value. Caches that don't implement BytesCache
+// fall back to Set, which copies the value.
+func SetBytes(cache Cache, key string, value []byte, ttl time.Duration) error {
+	if bc, ok := cache.(BytesCache); ok {
+		return bc.SetBytes(key, value, ttl)
+	}
+	return cache.Set(key, string(value), ttl)
+}
+
+// GetBytes gets a value as a byte slice. Caches that don't implement BytesCache
+// fall back to Get, which copies the value.
+func GetBytes(cache Cache, key string) ([]byte, error) {
+	if bc, ok := cache.(BytesCache); ok {
+		return bc.GetBytes(key)
+	}
+	value, err := cache.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+// AppendBytes appends a value to dst. Caches that don't implement BytesCache
+// fall back to Get. On error dst is returned unchanged.
+func AppendBytes(cache Cache, dst []byte, key string) ([]byte, error) {
+	if bc, ok := cache.(BytesCache); ok {
+		return bc.AppendBytes(dst, key)
+	}
+	value, err := cache.Get(key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
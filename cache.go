@@ -4,6 +4,22 @@ import (
 	"time"
 )
 
+// NoExpiry is returned by GetTTL for keys that don't expire.
+//
+// TTLs follow the same rules in every backend:
+//   - GetTTL returns ErrNotFound for missing keys, NoExpiry for keys without a TTL,
+//     and a positive duration otherwise
+//   - a TTL of 0 means no expiry: Set stores the key without a TTL, SetTTL removes its TTL
+//   - a negative TTL means the key has already expired: Set and SetTTL delete the key
+//   - SetTTL returns ErrNotFound for missing keys
+const NoExpiry time.Duration = -1
+
+// Cache is the common interface of backends and wrappers.
+//
+// Close releases the resources of the cache and waits for its background work to finish.
+// It can be called more than once, later calls return nil. Once it's closed, every other
+// method returns ErrCacheClosed. Closing a sub cache doesn't close the cache it was created from,
+// while closing a wrapper closes the caches it wraps.
 type Cache interface {
 	Set(key, value string, ttl time.Duration) error
 	Get(key string) (string, error)
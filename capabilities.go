@@ -0,0 +1,92 @@
+package razcache
+
+import (
+	"strings"
+)
+
+// Capability is a set of features a cache supports beyond the Cache interface
+type Capability uint32
+
+const (
+	CapLists      Capability = 1 << iota // LPush, RPush, LPop, RPop, LLen, LRange
+	CapSets                              // SAdd, SRem, SHas, SLen
+	CapCounters                          // Incr
+	CapScan                              // Scanner
+	CapExpire                            // Expirer
+	CapGetEx                             // Toucher, without falling back to Get and SetTTL
+	CapBytes                             // BytesCache, without converting values to strings
+	CapSetMembers                        // SetMemberLister
+	CapSetCount                          // SetCounter, without falling back to SLen
+
+	// CapExtended is the set of capabilities required by ExtendedCache
+	CapExtended = CapLists | CapSets | CapCounters
+
+	// capOptional is the set of capabilities of the optional interfaces
+	capOptional = CapScan | CapExpire | CapGetEx | CapBytes
+)
+
+var capabilityNames = []string{"lists", "sets", "counters", "scan", "expire", "getex", "bytes", "members", "setcount"}
+
+// Has reports if all capabilities of other are in c
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+func (c Capability) String() string {
+	var names []string
+	for i, name := range capabilityNames {
+		if c.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// CapabilityReporter is implemented by caches whose capabilities can't be told from their type,
+// like wrappers that forward optional interfaces to caches that might not implement them
+type CapabilityReporter interface {
+	Capabilities() Capability
+}
+
+// Capabilities returns the capabilities reported by the cache if it implements CapabilityReporter,
+// otherwise the ones of the interfaces it implements
+func Capabilities(cache Cache) Capability {
+	if reporter, ok := cache.(CapabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+	var caps Capability
+	if _, ok := cache.(ExtendedCache); ok {
+		caps |= CapExtended
+	}
+	if _, ok := cache.(Scanner); ok {
+		caps |= CapScan
+	}
+	if _, ok := cache.(Expirer); ok {
+		caps |= CapExpire
+	}
+	if _, ok := cache.(Toucher); ok {
+		caps |= CapGetEx
+	}
+	if _, ok := cache.(BytesCache); ok {
+		caps |= CapBytes
+	}
+	if _, ok := cache.(SetMemberLister); ok {
+		caps |= CapSetMembers
+	}
+	if _, ok := cache.(SetCounter); ok {
+		caps |= CapSetCount
+	}
+	return caps
+}
+
+// commonCapabilities returns the capabilities all of the caches have, or none without caches
+func commonCapabilities[T Cache](caches ...T) Capability {
+	if len(caches) == 0 {
+		return 0
+	}
+	caps := Capabilities(caches[0])
+	for _, cache := range caches[1:] {
+		caps &= Capabilities(cache)
+	}
+	return caps
+}
@@ -0,0 +1,112 @@
+package razcache_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+)
+
+// plainCache hides the optional interfaces of the cache it embeds
+type plainCache struct {
+	Cache
+}
+
+func TestCapabilities(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+	extCache := inmem.NewInMemExtendedCache()
+	defer extCache.Close()
+
+	native := CapScan | CapExpire | CapGetEx | CapBytes
+	assert.Equal(t, native, Capabilities(cache))
+	assert.Equal(t, CapExtended|CapSetMembers|CapSetCount|native, Capabilities(extCache))
+	assert.Equal(t, Capability(0), Capabilities(plainCache{cache}))
+
+	// listing set members is optional for extended caches
+	noMembers := struct{ ExtendedCache }{extCache}
+	assert.Equal(t, CapExtended, Capabilities(noMembers))
+	_, err := SMembers(noMembers, "set")
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	// prefix caches keep the capabilities, even if they are created as plain caches
+	sub := extCache.SubCache("prefix:")
+	assert.Equal(t, CapExtended|CapSetMembers|CapSetCount|native, Capabilities(sub))
+	_, ok := sub.(ExtendedCache)
+	assert.True(t, ok)
+	assert.Equal(t, native, Capabilities(cache.SubCache("prefix:")))
+	assert.Equal(t, Capability(0), Capabilities(NewPrefixCache(plainCache{cache}, "prefix:")))
+
+	// wrappers only report what they forward
+	assert.Equal(t, CapScan|CapGetEx, Capabilities(NewSlidingCache(extCache, time.Minute)))
+	assert.Equal(t, CapExtended|CapSetMembers|CapSetCount|CapScan|CapGetEx, Capabilities(NewSlidingExtendedCache(extCache, time.Minute)))
+}
+
+func TestWrapperCapabilities(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+	extCache := inmem.NewInMemExtendedCache()
+	defer extCache.Close()
+	require.NoError(t, extCache.Set("key", "value", 0))
+
+	native := CapScan | CapExpire | CapGetEx | CapBytes
+	encryption := EncryptionOptions{Keys: []EncryptionKey{{ID: "k1", Key: make([]byte, 16)}}, PlainCounters: true}
+	encrypted, err := NewEncryptedCache(cache, encryption)
+	require.NoError(t, err)
+	encryptedExt, err := NewEncryptedExtendedCache(extCache, encryption)
+	require.NoError(t, err)
+	sharded := NewShardedCache(10)
+	require.NoError(t, sharded.AddShard("a", cache, 1))
+	shardedExt := NewShardedExtendedCache(10)
+	require.NoError(t, shardedExt.AddShard("a", extCache, 1))
+
+	for name, tc := range map[string]struct {
+		cache Cache
+		caps  Capability
+	}{
+		"instrumented":     {NewInstrumentedCache(cache, NewStatsCollector(), MetricsLabels{}), native},
+		"instrumented ext": {NewInstrumentedExtendedCache(extCache, NewStatsCollector(), MetricsLabels{}), CapExtended | CapSetMembers | CapSetCount | native},
+		"middleware":       {NewMiddlewareCache(extCache), native},
+		"middleware ext":   {NewMiddlewareExtendedCache(extCache), CapExtended | CapSetMembers | CapSetCount | native},
+		"resilient":        {NewResilientCache(cache, ResilienceOptions{}), native},
+		"resilient ext":    {NewResilientExtendedCache(extCache, ResilienceOptions{}), CapExtended | CapSetMembers | CapSetCount | native},
+		"mirror":           {NewMirrorCache(cache, []Cache{plainCache{cache}}, MirrorOptions{}), CapScan | CapGetEx},
+		"mirror ext":       {NewMirrorExtendedCache(extCache, []ExtendedCache{extCache}, MirrorOptions{}), CapExtended | CapSetMembers | CapSetCount | native},
+		"sharded":          {sharded, native},
+		"sharded ext":      {shardedExt, CapExtended | CapSetMembers | CapSetCount | native},
+		"encrypted":        {encrypted, CapScan | CapExpire | CapGetEx},
+		"encrypted ext":    {encryptedExt, CapExtended | CapSetMembers | CapSetCount | CapScan | CapExpire | CapGetEx},
+	} {
+		assert.Equal(t, tc.caps, Capabilities(tc.cache), name)
+		sub := tc.cache.SubCache("prefix:")
+		assert.Equal(t, tc.caps, Capabilities(sub), name)
+		_, isExt := sub.(ExtendedCache)
+		assert.Equal(t, tc.caps.Has(CapExtended), isExt, name)
+	}
+
+	// wrappers don't hide the keys from Export
+	var sb strings.Builder
+	n, err := Export(NewMiddlewareExtendedCache(extCache), "", &sb)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// hashed keys can't be scanned
+	encryption.KeyHashSecret = []byte("secret")
+	encrypted, err = NewEncryptedCache(cache, encryption)
+	require.NoError(t, err)
+	assert.Equal(t, CapExpire|CapGetEx, Capabilities(encrypted))
+	assert.Equal(t, ErrNotSupported, Scan(encrypted, "", func(string) bool { return true }))
+}
+
+func TestCapabilityString(t *testing.T) {
+	assert.Equal(t, "lists|sets|counters", CapExtended.String())
+	assert.Equal(t, "scan|bytes", (CapScan | CapBytes).String())
+	assert.Equal(t, "", Capability(0).String())
+	assert.True(t, CapExtended.Has(CapLists|CapSets))
+	assert.False(t, CapLists.Has(CapExtended))
+}
@@ -0,0 +1,53 @@
+package razcache
+
+import (
+	"time"
+)
+
+// closedCache returns ErrCacheClosed for everything, so wrappers that can be closed
+// without closing the cache they wrap can forward to it instead
+type closedCache struct{}
+
+func (closedCache) Set(key, value string, ttl time.Duration) error       { return ErrCacheClosed }
+func (closedCache) Get(key string) (string, error)                       { return "", ErrCacheClosed }
+func (closedCache) Del(key string) error                                 { return ErrCacheClosed }
+func (closedCache) GetTTL(key string) (time.Duration, error)             { return 0, ErrCacheClosed }
+func (closedCache) SetTTL(key string, ttl time.Duration) error           { return ErrCacheClosed }
+func (closedCache) LPush(key string, values ...string) error             { return ErrCacheClosed }
+func (closedCache) RPush(key string, values ...string) error             { return ErrCacheClosed }
+func (closedCache) LPop(key string, count int) ([]string, error)         { return nil, ErrCacheClosed }
+func (closedCache) RPop(key string, count int) ([]string, error)         { return nil, ErrCacheClosed }
+func (closedCache) LLen(key string) (int, error)                         { return 0, ErrCacheClosed }
+func (closedCache) LRange(key string, start, stop int) ([]string, error) { return nil, ErrCacheClosed }
+func (closedCache) SAdd(key string, values ...string) error              { return ErrCacheClosed }
+func (closedCache) SRem(key string, values ...string) error              { return ErrCacheClosed }
+func (closedCache) SHas(key, value string) (bool, error)                 { return false, ErrCacheClosed }
+func (closedCache) SLen(key string) (int, error)                         { return 0, ErrCacheClosed }
+func (closedCache) SMembers(key string) ([]string, error)                { return nil, ErrCacheClosed }
+func (closedCache) SAddCount(key string, values ...string) (int, error)  { return 0, ErrCacheClosed }
+func (closedCache) SRemCount(key string, values ...string) (int, error)  { return 0, ErrCacheClosed }
+func (closedCache) Incr(key string, increment int64) (int64, error)      { return 0, ErrCacheClosed }
+func (closedCache) Scan(prefix string, fn func(key string) bool) error   { return ErrCacheClosed }
+func (closedCache) GetEx(key string, ttl time.Duration) (string, error)  { return "", ErrCacheClosed }
+func (closedCache) Persist(key string) error                             { return ErrCacheClosed }
+func (closedCache) SetKeepTTL(key, value string) error                   { return ErrCacheClosed }
+func (closedCache) GetBytes(key string) ([]byte, error)                  { return nil, ErrCacheClosed }
+func (closedCache) SubCache(prefix string) Cache                         { return closedCache{} }
+func (closedCache) SubExtendedCache(prefix string) ExtendedCache         { return closedCache{} }
+func (closedCache) Close() error                                         { return nil }
+
+func (closedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	return false, ErrCacheClosed
+}
+
+func (closedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	return false, ErrCacheClosed
+}
+
+func (closedCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return ErrCacheClosed
+}
+
+func (closedCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	return dst, ErrCacheClosed
+}
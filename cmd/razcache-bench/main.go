@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v4"
+
+	"github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/badger"
+	"github.com/razzie/razcache/pkg/httpcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/redis"
+	"github.com/razzie/razcache/pkg/server/servertest"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+type backend struct {
+	name string
+	open func() (razcache.Cache, func() error, error)
+}
+
+func main() {
+	backendNames := flag.String("backends", "inmem,inmem-ext,badger,redis,http", "comma separated list of backends to compare")
+	redisDSN := flag.String("redis", "", "redis DSN to benchmark instead of an in-process server (keys are written under a temporary prefix)")
+	run := flag.String("run", "", "only run benchmarks matching this regular expression")
+	benchtime := flag.String("benchtime", "1s", "run each benchmark for this duration or Nx times")
+	flag.Parse()
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	testing.Init()
+	if err := flag.Set("test.benchtime", *benchtime); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var backends []backend
+	for _, name := range strings.Split(*backendNames, ",") {
+		b, err := newBackend(strings.TrimSpace(name), *redisDSN)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		backends = append(backends, b)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "benchmark\t")
+	for _, b := range backends {
+		fmt.Fprintf(w, "%s\t", b.name)
+	}
+	fmt.Fprintln(w)
+	for _, bm := range testutil.Benchmarks() {
+		if !filter.MatchString(bm.Name) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t", bm.Name)
+		for _, b := range backends {
+			fmt.Fprintf(w, "%s\t", runBenchmark(bm, b))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// runBenchmark returns the formatted ns/op of a benchmark, or the reason it didn't run
+func runBenchmark(bm testutil.Benchmark, b backend) string {
+	var failure atomic.Value
+	result := testing.Benchmark(func(tb *testing.B) {
+		cache, closeFn, err := b.open()
+		if err != nil {
+			failure.Store(err.Error())
+			tb.SkipNow()
+		}
+		defer closeFn()
+		if !razcache.Capabilities(cache).Has(bm.Requires) {
+			failure.Store("-")
+			tb.SkipNow()
+		}
+		bm.Run(tb, cache)
+		if tb.Failed() {
+			failure.Store("failed")
+		}
+	})
+	if msg, ok := failure.Load().(string); ok {
+		return msg
+	}
+	return fmt.Sprintf("%.1f ns/op", float64(result.T.Nanoseconds())/float64(result.N))
+}
+
+func newBackend(name, redisDSN string) (backend, error) {
+	b := backend{name: name}
+	switch name {
+	case "inmem":
+		b.open = func() (razcache.Cache, func() error, error) {
+			cache := inmem.NewInMemCache()
+			return cache, cache.Close, nil
+		}
+	case "inmem-ext":
+		b.open = func() (razcache.Cache, func() error, error) {
+			cache := inmem.NewInMemExtendedCache()
+			return cache, cache.Close, nil
+		}
+	case "badger":
+		b.open = func() (razcache.Cache, func() error, error) {
+			db, err := badgerdb.Open(badgerdb.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+			if err != nil {
+				return nil, nil, err
+			}
+			cache := badger.NewBadgerCacheFromDB(db)
+			return cache, cache.Close, nil
+		}
+	case "redis":
+		if len(redisDSN) > 0 {
+			b.open = openRedis(redisDSN)
+			break
+		}
+		b.open = func() (razcache.Cache, func() error, error) {
+			srv := servertest.NewServer()
+			cache, err := redis.NewRedisCache(srv.URL())
+			if err != nil {
+				srv.Close()
+				return nil, nil, err
+			}
+			return cache, func() error {
+				defer srv.Close()
+				return cache.Close()
+			}, nil
+		}
+	case "http":
+		b.open = func() (razcache.Cache, func() error, error) {
+			backend := inmem.NewInMemExtendedCache()
+			srv := httptest.NewServer(httpcache.NewHandler(backend))
+			cache := httpcache.NewHTTPCache(srv.URL)
+			return cache, func() error {
+				defer backend.Close()
+				defer srv.Close()
+				return cache.Close()
+			}, nil
+		}
+	default:
+		return b, fmt.Errorf("unknown backend: %q", name)
+	}
+	return b, nil
+}
+
+// openRedis isolates each benchmark run of a shared redis server under a new prefix,
+// and deletes the keys of the run when it's done
+func openRedis(redisDSN string) func() (razcache.Cache, func() error, error) {
+	var runs atomic.Int64
+	return func() (razcache.Cache, func() error, error) {
+		client, err := redis.NewRedisCache(redisDSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		prefix := fmt.Sprintf("razcache-bench:%d:%d:", time.Now().UnixNano(), runs.Add(1))
+		cache := razcache.NewPrefixExtendedCache(client, prefix)
+		return cache, func() error {
+			defer client.Close()
+			keys, err := razcache.ScanKeys(cache, "")
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := cache.Del(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+}
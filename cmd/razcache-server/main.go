@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/httpcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/server"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:6379", "address to serve the Redis protocol on (without authentication, so only expose it to trusted networks)")
+	httpAddr := flag.String("http", "", "address to serve the HTTP API on, like localhost:8080 (disabled if empty)")
+	flag.Parse()
+
+	cache := inmem.NewInMemExtendedCache()
+	err := serve(cache, *addr, *httpAddr)
+	cache.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// serve runs the servers until a signal arrives or one of them fails, then closes them
+func serve(cache razcache.ExtendedCache, addr, httpAddr string) error {
+	errc := make(chan error, 2)
+
+	srv := server.NewServer(cache)
+	defer srv.Close()
+	go func() {
+		log.Println("razcache-server listening on", addr)
+		errc <- srv.ListenAndServe(addr)
+	}()
+
+	if len(httpAddr) > 0 {
+		httpSrv := &http.Server{
+			Addr:    httpAddr,
+			Handler: httpcache.NewHandler(cache),
+		}
+		defer httpSrv.Close()
+		go func() {
+			log.Println("razcache-server serving HTTP on", httpAddr)
+			errc <- httpSrv.ListenAndServe()
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-sig:
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
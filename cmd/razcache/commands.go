@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+var errNotExtended = errors.New("the backend doesn't support lists, sets and counters")
+
+type command struct {
+	args    string
+	help    string
+	minArgs int
+	maxArgs int // -1 means unlimited
+	run     func(c *cli, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	// initialized here to avoid an initialization cycle with the help command
+	commands = map[string]command{
+		"get":     {"KEY", "print the value of a key", 1, 1, (*cli).get},
+		"set":     {"KEY VALUE [TTL]", "set the value of a key, TTL is a duration like 10m", 2, 3, (*cli).set},
+		"del":     {"KEY...", "delete keys", 1, -1, (*cli).del},
+		"ttl":     {"KEY [TTL]", "print the remaining TTL of a key, or set it (0 removes it, negative deletes the key)", 1, 2, (*cli).ttl},
+		"scan":    {"[PREFIX]", "list the keys starting with prefix", 0, 1, (*cli).scan},
+		"list":    {"lpush|rpush|lpop|rpop|len|range KEY [ARGS...]", "list operations", 2, -1, (*cli).list},
+		"sets":    {"add|rem|has|len|members KEY [MEMBERS...]", "set operations", 2, -1, (*cli).sets},
+		"incr":    {"KEY [INCREMENT]", "increment a counter and print its new value", 1, 2, (*cli).incr},
+		"export":  {"FILE|- [PREFIX]", "write the keys starting with prefix in dump format", 1, 2, (*cli).export},
+		"import":  {"FILE|-", "load keys from a dump, overwriting existing ones", 1, 1, (*cli).importDump},
+		"migrate": {"TARGET [PREFIX]", "copy keys to redis://..., badger:DIR or inmem:FILE", 1, 2, (*cli).migrate},
+		"help":    {"", "print this help", 0, 0, (*cli).help},
+	}
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  %-7s %-50s %s\n", name, cmd.args, cmd.help)
+	}
+}
+
+type cli struct {
+	cache razcache.Cache
+	in    io.Reader
+	out   io.Writer
+}
+
+func (c *cli) exec(args []string) error {
+	name, args := args[0], args[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q (try help)", name)
+	}
+	if len(args) < cmd.minArgs || (cmd.maxArgs >= 0 && len(args) > cmd.maxArgs) {
+		return fmt.Errorf("usage: %s %s", name, cmd.args)
+	}
+	return cmd.run(c, args)
+}
+
+func (c *cli) ext() (razcache.ExtendedCache, error) {
+	if ext, ok := c.cache.(razcache.ExtendedCache); ok {
+		return ext, nil
+	}
+	return nil, errNotExtended
+}
+
+func (c *cli) println(values ...string) {
+	for _, value := range values {
+		fmt.Fprintln(c.out, value)
+	}
+}
+
+func (c *cli) get(args []string) error {
+	value, err := c.cache.Get(args[0])
+	if err != nil {
+		return err
+	}
+	c.println(value)
+	return nil
+}
+
+func (c *cli) set(args []string) error {
+	var ttl time.Duration
+	if len(args) > 2 {
+		var err error
+		if ttl, err = parseTTL(args[2]); err != nil {
+			return err
+		}
+	}
+	return c.cache.Set(args[0], args[1], ttl)
+}
+
+func (c *cli) del(args []string) error {
+	for _, key := range args {
+		if err := c.cache.Del(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cli) ttl(args []string) error {
+	if len(args) > 1 {
+		ttl, err := parseTTL(args[1])
+		if err != nil {
+			return err
+		}
+		return c.cache.SetTTL(args[0], ttl)
+	}
+	ttl, err := c.cache.GetTTL(args[0])
+	if err != nil {
+		return err
+	}
+	if ttl == razcache.NoExpiry {
+		c.println("no TTL")
+	} else {
+		c.println(ttl.Round(time.Millisecond).String())
+	}
+	return nil
+}
+
+func (c *cli) scan(args []string) error {
+	var prefix string
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+	return razcache.Scan(c.cache, prefix, func(key string) bool {
+		c.println(key)
+		return true
+	})
+}
+
+func (c *cli) list(args []string) error {
+	cache, err := c.ext()
+	if err != nil {
+		return err
+	}
+	op, key, args := args[0], args[1], args[2:]
+	switch op {
+	case "lpush", "rpush":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: list %s KEY VALUE...", op)
+		}
+		if op == "lpush" {
+			return cache.LPush(key, args...)
+		}
+		return cache.RPush(key, args...)
+	case "lpop", "rpop":
+		count := 1
+		if len(args) > 0 {
+			if count, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid count: %s", args[0])
+			}
+		}
+		var values []string
+		if op == "lpop" {
+			values, err = cache.LPop(key, count)
+		} else {
+			values, err = cache.RPop(key, count)
+		}
+		c.println(values...)
+		return err
+	case "len":
+		length, err := cache.LLen(key)
+		if err != nil {
+			return err
+		}
+		c.println(strconv.Itoa(length))
+		return nil
+	case "range":
+		start, stop := 0, -1
+		if len(args) > 0 {
+			if len(args) != 2 {
+				return errors.New("usage: list range KEY [START STOP]")
+			}
+			if start, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid start: %s", args[0])
+			}
+			if stop, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid stop: %s", args[1])
+			}
+		}
+		values, err := cache.LRange(key, start, stop)
+		c.println(values...)
+		return err
+	default:
+		return fmt.Errorf("unknown list operation %q", op)
+	}
+}
+
+func (c *cli) sets(args []string) error {
+	cache, err := c.ext()
+	if err != nil {
+		return err
+	}
+	op, key, args := args[0], args[1], args[2:]
+	switch op {
+	case "add":
+		return cache.SAdd(key, args...)
+	case "rem":
+		return cache.SRem(key, args...)
+	case "has":
+		if len(args) != 1 {
+			return errors.New("usage: sets has KEY MEMBER")
+		}
+		found, err := cache.SHas(key, args[0])
+		if err != nil {
+			return err
+		}
+		c.println(strconv.FormatBool(found))
+		return nil
+	case "len":
+		length, err := cache.SLen(key)
+		if err != nil {
+			return err
+		}
+		c.println(strconv.Itoa(length))
+		return nil
+	case "members":
+		members, err := razcache.SMembers(cache, key)
+		c.println(members...)
+		return err
+	default:
+		return fmt.Errorf("unknown set operation %q", op)
+	}
+}
+
+func (c *cli) incr(args []string) error {
+	cache, err := c.ext()
+	if err != nil {
+		return err
+	}
+	increment := int64(1)
+	if len(args) > 1 {
+		if increment, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return fmt.Errorf("invalid increment: %s", args[1])
+		}
+	}
+	value, err := cache.Incr(args[0], increment)
+	if err != nil {
+		return err
+	}
+	c.println(strconv.FormatInt(value, 10))
+	return nil
+}
+
+func (c *cli) export(args []string) error {
+	var prefix string
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+	if args[0] == "-" {
+		_, err := razcache.Export(c.cache, prefix, c.out)
+		return err
+	}
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	count, err := razcache.Export(c.cache, prefix, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	c.println(fmt.Sprintf("exported %d keys", count))
+	return nil
+}
+
+func (c *cli) importDump(args []string) error {
+	in := c.in
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	count, err := razcache.Import(c.cache, in)
+	if err != nil {
+		return fmt.Errorf("imported %d keys: %w", count, err)
+	}
+	c.println(fmt.Sprintf("imported %d keys", count))
+	return nil
+}
+
+func (c *cli) migrate(args []string) error {
+	var prefix string
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+	target, err := openTarget(args[0])
+	if err != nil {
+		return err
+	}
+	count, err := razcache.Migrate(target.cache, c.cache, prefix)
+	if closeErr := target.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("migrated %d keys: %w", count, err)
+	}
+	c.println(fmt.Sprintf("migrated %d keys", count))
+	return nil
+}
+
+func (c *cli) help([]string) error {
+	printUsage(c.out)
+	return nil
+}
+
+func parseTTL(s string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid TTL: %s", s)
+	}
+	return ttl, nil
+}
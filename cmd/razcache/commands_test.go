@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razzie/razcache/pkg/inmem"
+)
+
+func TestExec(t *testing.T) {
+	dump := filepath.Join(t.TempDir(), "dump.jsonl")
+
+	// the steps run in order against the same cache
+	tests := []struct {
+		args string
+		out  string
+		err  string
+	}{
+		{"nope", "", "unknown command"},
+		{"get", "", "usage: get KEY"},
+		{"get a b", "", "usage: get KEY"},
+		{"get key", "", "not found"},
+		{"set key value", "", ""},
+		{"get key", "value\n", ""},
+		{"ttl key", "no TTL\n", ""},
+		{"set key value 10m", "", ""},
+		{"ttl key", "10m0s\n", ""},
+		{"set key value ten", "", "invalid TTL"},
+		{"ttl key 0", "", ""},
+		{"ttl key", "no TTL\n", ""},
+		{"list rpush queue a b c", "", ""},
+		{"list push queue a", "", "unknown list operation"},
+		{"list lpush queue", "", "usage: list lpush KEY VALUE..."},
+		{"list len queue", "3\n", ""},
+		{"list range queue", "a\nb\nc\n", ""},
+		{"list range queue 1", "", "usage: list range"},
+		{"list range queue 1 -1", "b\nc\n", ""},
+		{"list lpop queue", "a\n", ""},
+		{"list rpop queue 5", "b\nc\n", ""},
+		{"list rpop queue x", "", "invalid count"},
+		{"sets add tags x y", "", ""},
+		{"sets has tags x", "true\n", ""},
+		{"sets has tags", "", "usage: sets has"},
+		{"sets rem tags x", "", ""},
+		{"sets members tags", "y\n", ""},
+		{"sets len tags", "1\n", ""},
+		{"sets len key", "", "wrong type"},
+		{"incr counter", "1\n", ""},
+		{"incr counter 10", "11\n", ""},
+		{"incr counter x", "", "invalid increment"},
+		{"scan ke", "key\n", ""},
+		{"del key tags queue", "", ""},
+		{"export - counter", "{\"key\":\"counter\",\"type\":\"string\",\"value\":\"11\"}\n", ""},
+		{"export " + dump, "exported 1 keys\n", ""},
+		{"del counter", "", ""},
+		{"import " + dump, "imported 1 keys\n", ""},
+		{"get counter", "11\n", ""},
+		{"migrate nope:", "", "invalid target"},
+	}
+
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+	for _, tc := range tests {
+		var out bytes.Buffer
+		c := &cli{cache: cache, out: &out}
+		err := c.exec(strings.Fields(tc.args))
+		if len(tc.err) > 0 {
+			assert.ErrorContains(t, err, tc.err, tc.args)
+		} else {
+			assert.NoError(t, err, tc.args)
+		}
+		assert.Equal(t, tc.out, out.String(), tc.args)
+	}
+}
+
+func TestExecNotExtended(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+	c := &cli{cache: cache, out: &bytes.Buffer{}}
+
+	for _, args := range []string{"list len key", "sets len key", "incr key"} {
+		assert.Equal(t, errNotExtended, c.exec(strings.Fields(args)), args)
+	}
+	assert.NoError(t, c.exec([]string{"set", "key", "value"}))
+	assert.NoError(t, c.exec([]string{"get", "key"}))
+}
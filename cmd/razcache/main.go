@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v4"
+
+	"github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/badger"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/redis"
+)
+
+func main() {
+	badgerDir := flag.String("badger", "", "badger directory to open")
+	readOnly := flag.Bool("readonly", false, "open the badger directory read-only, so it can be inspected while another process has it open")
+	redisDSN := flag.String("redis", "", "redis DSN to connect to (e.g. redis://localhost:6379/0)")
+	snapshot := flag.String("inmem", "", "inmem snapshot file in dump format to open (created on exit if missing, saved on exit if written to)")
+	prefix := flag.String("prefix", "", "only operate on keys with this prefix")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command [args...]]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Without a command it starts an interactive shell.\n\nFlags:")
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), "\nCommands:")
+		printUsage(flag.CommandLine.Output())
+	}
+	flag.Parse()
+
+	b, err := openBackend(*badgerDir, *redisDSN, *snapshot, *readOnly)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	c := &cli{cache: b.cache, in: os.Stdin, out: os.Stdout}
+	if len(*prefix) > 0 {
+		c.cache = subCache(b.cache, *prefix)
+	}
+
+	if flag.NArg() > 0 {
+		err = c.exec(flag.Args())
+	} else {
+		err = c.repl(os.Stdin, isTerminal(os.Stdin))
+	}
+	if closeErr := b.close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type backend struct {
+	cache razcache.Cache
+	close func() error
+}
+
+func openBackend(badgerDir, redisDSN, snapshot string, readOnly bool) (*backend, error) {
+	var opened []string
+	for name, value := range map[string]string{"-badger": badgerDir, "-redis": redisDSN, "-inmem": snapshot} {
+		if len(value) > 0 {
+			opened = append(opened, name)
+		}
+	}
+	if len(opened) != 1 {
+		return nil, errors.New("exactly one of -badger, -redis or -inmem is required")
+	}
+	if readOnly && len(badgerDir) == 0 {
+		return nil, errors.New("-readonly is only supported with -badger")
+	}
+
+	switch {
+	case len(badgerDir) > 0:
+		db, err := badgerdb.Open(badgerdb.DefaultOptions(badgerDir).WithLogger(nil).WithReadOnly(readOnly))
+		if err != nil {
+			return nil, err
+		}
+		cache := badger.NewBadgerCacheFromDB(db)
+		return &backend{cache: cache, close: cache.Close}, nil
+	case len(redisDSN) > 0:
+		cache, err := redis.NewRedisCache(redisDSN)
+		if err != nil {
+			return nil, err
+		}
+		return &backend{cache: cache, close: cache.Close}, nil
+	default:
+		return openSnapshot(snapshot)
+	}
+}
+
+// openTarget opens a backend given as redis://..., badger:DIR or inmem:FILE
+func openTarget(target string) (*backend, error) {
+	switch {
+	case strings.HasPrefix(target, "redis://"), strings.HasPrefix(target, "rediss://"):
+		return openBackend("", target, "", false)
+	case strings.HasPrefix(target, "badger:"):
+		return openBackend(strings.TrimPrefix(target, "badger:"), "", "", false)
+	case strings.HasPrefix(target, "inmem:"):
+		return openBackend("", "", strings.TrimPrefix(target, "inmem:"), false)
+	default:
+		return nil, fmt.Errorf("invalid target %q (expected redis://..., badger:DIR or inmem:FILE)", target)
+	}
+}
+
+// openSnapshot loads the snapshot into an inmem cache and saves it back on close
+// if it was missing or the cache was written to
+func openSnapshot(path string) (*backend, error) {
+	mem := inmem.NewInMemExtendedCache()
+	var written bool
+	if f, err := os.Open(path); err == nil {
+		_, err = razcache.Import(mem, f)
+		f.Close()
+		if err != nil {
+			mem.Close()
+			return nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+	} else if os.IsNotExist(err) {
+		written = true
+	} else {
+		mem.Close()
+		return nil, err
+	}
+
+	cache := &writeTracker{ExtendedCache: mem, written: &written}
+	close := func() error {
+		defer cache.Close()
+		if !written {
+			return nil
+		}
+		tmp := path + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		if _, err := razcache.Export(cache, "", f); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return os.Rename(tmp, path)
+	}
+	return &backend{cache: cache, close: close}, nil
+}
+
+// writeTracker records calls to the write methods of the cache, including failed ones,
+// as they might have changed some of the keys anyway
+type writeTracker struct {
+	razcache.ExtendedCache
+	written *bool
+}
+
+func (c *writeTracker) write() {
+	*c.written = true
+}
+
+func (c *writeTracker) Set(key, value string, ttl time.Duration) error {
+	c.write()
+	return c.ExtendedCache.Set(key, value, ttl)
+}
+
+func (c *writeTracker) Del(key string) error {
+	c.write()
+	return c.ExtendedCache.Del(key)
+}
+
+func (c *writeTracker) SetTTL(key string, ttl time.Duration) error {
+	c.write()
+	return c.ExtendedCache.SetTTL(key, ttl)
+}
+
+func (c *writeTracker) LPush(key string, values ...string) error {
+	c.write()
+	return c.ExtendedCache.LPush(key, values...)
+}
+
+func (c *writeTracker) RPush(key string, values ...string) error {
+	c.write()
+	return c.ExtendedCache.RPush(key, values...)
+}
+
+func (c *writeTracker) LPop(key string, count int) ([]string, error) {
+	c.write()
+	return c.ExtendedCache.LPop(key, count)
+}
+
+func (c *writeTracker) RPop(key string, count int) ([]string, error) {
+	c.write()
+	return c.ExtendedCache.RPop(key, count)
+}
+
+func (c *writeTracker) SAdd(key string, values ...string) error {
+	c.write()
+	return c.ExtendedCache.SAdd(key, values...)
+}
+
+func (c *writeTracker) SRem(key string, values ...string) error {
+	c.write()
+	return c.ExtendedCache.SRem(key, values...)
+}
+
+func (c *writeTracker) Incr(key string, increment int64) (int64, error) {
+	c.write()
+	return c.ExtendedCache.Incr(key, increment)
+}
+
+func (c *writeTracker) Scan(prefix string, fn func(key string) bool) error {
+	return razcache.Scan(c.ExtendedCache, prefix, fn)
+}
+
+func (c *writeTracker) SMembers(key string) ([]string, error) {
+	return razcache.SMembers(c.ExtendedCache, key)
+}
+
+func (c *writeTracker) SubCache(prefix string) razcache.Cache {
+	return c.SubExtendedCache(prefix)
+}
+
+func (c *writeTracker) SubExtendedCache(prefix string) razcache.ExtendedCache {
+	return &writeTracker{ExtendedCache: c.ExtendedCache.SubExtendedCache(prefix), written: c.written}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func subCache(cache razcache.Cache, prefix string) razcache.Cache {
+	if ext, ok := cache.(razcache.ExtendedCache); ok {
+		return ext.SubExtendedCache(prefix)
+	}
+	return cache.SubCache(prefix)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/razzie/razcache/pkg/server/servertest"
+)
+
+func TestOpenTarget(t *testing.T) {
+	srv := servertest.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+
+	tests := []struct {
+		target string
+		err    bool
+	}{
+		{srv.URL(), false},
+		{"badger:" + filepath.Join(dir, "badger"), false},
+		{"inmem:" + filepath.Join(dir, "snapshot.jsonl"), false},
+		{"redis:/localhost", true},
+		{"badger:", true},
+		{"inmem:", true},
+		{filepath.Join(dir, "snapshot.jsonl"), true},
+		{"", true},
+	}
+	for _, tc := range tests {
+		b, err := openTarget(tc.target)
+		if tc.err {
+			assert.Error(t, err, tc.target)
+			continue
+		}
+		if assert.NoError(t, err, tc.target) {
+			assert.NoError(t, b.cache.Set("key", "value", 0), tc.target)
+			value, err := b.cache.Get("key")
+			assert.NoError(t, err, tc.target)
+			assert.Equal(t, "value", value, tc.target)
+			assert.NoError(t, b.close(), tc.target)
+		}
+	}
+}
+
+func TestOpenBackendReadOnly(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "badger")
+	b, err := openBackend(dir, "", "", false)
+	require.NoError(t, err)
+	require.NoError(t, b.cache.Set("key", "value", 0))
+	require.NoError(t, b.close())
+
+	b, err = openBackend(dir, "", "", true)
+	require.NoError(t, err)
+	value, err := b.cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Error(t, b.cache.Set("key", "value2", 0))
+	assert.NoError(t, b.close())
+
+	_, err = openBackend("", "", filepath.Join(t.TempDir(), "snapshot.jsonl"), true)
+	assert.Error(t, err)
+}
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+
+	// missing snapshots are created
+	b, err := openSnapshot(path)
+	require.NoError(t, err)
+	require.NoError(t, b.close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	// sessions that only read don't rewrite the snapshot
+	written := `{"type":"string","key":"key","value":"value"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(written), 0o644))
+	b, err = openSnapshot(path)
+	require.NoError(t, err)
+	c := &cli{cache: b.cache, out: io.Discard}
+	for _, args := range [][]string{{"get", "key"}, {"ttl", "key"}, {"scan"}, {"export", "-"}, {"get", "missing"}, {"list", "range", "key"}, {"sets", "members", "key"}, {"sets", "has", "key", "value"}} {
+		c.exec(args)
+	}
+	require.NoError(t, b.close())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, written, string(data))
+
+	// writes are saved, even through sub caches
+	b, err = openSnapshot(path)
+	require.NoError(t, err)
+	c = &cli{cache: subCache(b.cache, "sub:"), out: io.Discard}
+	require.NoError(t, c.exec([]string{"set", "key", "value2"}))
+	require.NoError(t, b.close())
+	b, err = openSnapshot(path)
+	require.NoError(t, err)
+	defer b.close()
+	value, err := b.cache.Get("sub:key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", value)
+	value, err = b.cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
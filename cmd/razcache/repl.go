@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// repl executes commands line by line, printing a prompt if interactive
+func (c *cli) repl(in io.Reader, interactive bool) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 16*1024*1024)
+	for {
+		if interactive {
+			fmt.Fprint(c.out, "razcache> ")
+		}
+		if !scanner.Scan() {
+			if interactive {
+				fmt.Fprintln(c.out)
+			}
+			return scanner.Err()
+		}
+		args, err := splitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintln(c.out, "error:", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "quit" || args[0] == "exit" {
+			return nil
+		}
+		if err := c.exec(args); err != nil {
+			fmt.Fprintln(c.out, "error:", err)
+		}
+	}
+}
+
+// splitArgs splits a line by whitespace while respecting single and double quotes.
+// Backslash escapes are only recognized within double quotes.
+func splitArgs(line string) (args []string, err error) {
+	var arg strings.Builder
+	var inArg, escaped bool
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			switch {
+			case r == quote:
+				quote = 0
+			case r == '\\' && quote == '"':
+				escaped = true
+			default:
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razzie/razcache/pkg/inmem"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+		err  bool
+	}{
+		{"", nil, false},
+		{"   \t ", nil, false},
+		{"get key", []string{"get", "key"}, false},
+		{"  set\tkey  value ", []string{"set", "key", "value"}, false},
+		{`set key "hello world"`, []string{"set", "key", "hello world"}, false},
+		{`set key 'hello world'`, []string{"set", "key", "hello world"}, false},
+		{`set key ""`, []string{"set", "key", ""}, false},
+		{`set key a"b c"d`, []string{"set", "key", "ab cd"}, false},
+		{`set key "say \"hi\" \\o/"`, []string{"set", "key", `say "hi" \o/`}, false},
+		{`set key 'no \escapes'`, []string{"set", "key", `no \escapes`}, false},
+		{`set key "it's"`, []string{"set", "key", "it's"}, false},
+		{`set key "unterminated`, nil, true},
+		{`set key 'unterminated`, nil, true},
+		{`set key "escaped\`, nil, true},
+	}
+	for _, tc := range tests {
+		args, err := splitArgs(tc.line)
+		if tc.err {
+			assert.Error(t, err, tc.line)
+			continue
+		}
+		assert.NoError(t, err, tc.line)
+		assert.Equal(t, tc.args, args, tc.line)
+	}
+}
+
+func TestRepl(t *testing.T) {
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+	var out bytes.Buffer
+	c := &cli{cache: cache, out: &out}
+
+	in := strings.NewReader("set key 'hello world'\n\nget key\nget missing\nset \"key\nquit\nget key\n")
+	assert.NoError(t, c.repl(in, false))
+	assert.Equal(t, "hello world\nerror: not found\nerror: unterminated quote\n", out.String())
+}
@@ -0,0 +1,232 @@
+package razcache
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of dump entries
+const (
+	DumpString = "string"
+	DumpList   = "list"
+	DumpSet    = "set"
+)
+
+// DumpBase64 is the encoding of entries whose key or values aren't valid UTF-8,
+// as JSON strings can't hold arbitrary bytes
+const DumpBase64 = "base64"
+
+// DumpEntry is a single key of a dump, written as one line of JSON by Export
+type DumpEntry struct {
+	Key    string   `json:"key"`
+	Type   string   `json:"type"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+	TTL    int64    `json:"ttl,omitempty"` // remaining milliseconds, or 0 if the key doesn't expire
+}
+
+type dumpEntry DumpEntry
+
+type encodedDumpEntry struct {
+	dumpEntry
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// MarshalJSON encodes the key and values in base64 if any of them isn't valid UTF-8
+func (e DumpEntry) MarshalJSON() ([]byte, error) {
+	out := encodedDumpEntry{dumpEntry: dumpEntry(e)}
+	if !e.validUTF8() {
+		out.Encoding = DumpBase64
+		out.Key = base64.StdEncoding.EncodeToString([]byte(e.Key))
+		out.Value = base64.StdEncoding.EncodeToString([]byte(e.Value))
+		out.Values = make([]string, len(e.Values))
+		for i, value := range e.Values {
+			out.Values[i] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes entries written by MarshalJSON
+func (e *DumpEntry) UnmarshalJSON(data []byte) error {
+	var in encodedDumpEntry
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	switch in.Encoding {
+	case "":
+	case DumpBase64:
+		var err error
+		if in.Key, err = decodeBase64(in.Key); err != nil {
+			return err
+		}
+		if in.Value, err = decodeBase64(in.Value); err != nil {
+			return err
+		}
+		for i, value := range in.Values {
+			if in.Values[i], err = decodeBase64(value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown dump encoding: %s", in.Encoding)
+	}
+	*e = DumpEntry(in.dumpEntry)
+	return nil
+}
+
+func (e *DumpEntry) validUTF8() bool {
+	if !utf8.ValidString(e.Key) || !utf8.ValidString(e.Value) {
+		return false
+	}
+	for _, value := range e.Values {
+		if !utf8.ValidString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeBase64(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	return string(data), err
+}
+
+// Export writes the keys starting with prefix as JSON lines of DumpEntry.
+// The cache has to implement Scanner, otherwise ErrNotSupported is returned.
+// Keys that disappear during the export are skipped, and so are sets
+// if the cache doesn't implement SetMemberLister.
+func Export(cache Cache, prefix string, w io.Writer) (count int, err error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	err = exportEntries(cache, prefix, func(entry *DumpEntry) error {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	err = bw.Flush()
+	return
+}
+
+// Import reads the output of Export and writes its entries to the cache,
+// overwriting existing keys. Lists and sets require an ExtendedCache.
+func Import(cache Cache, r io.Reader) (count int, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var entry DumpEntry
+		if err = dec.Decode(&entry); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		if err = ImportEntry(cache, &entry); err != nil {
+			return
+		}
+		count++
+	}
+}
+
+// Migrate copies the keys starting with prefix from src to dst without
+// buffering the whole content. Keys are skipped the same way as by Export.
+func Migrate(dst, src Cache, prefix string) (count int, err error) {
+	err = exportEntries(src, prefix, func(entry *DumpEntry) error {
+		if err := ImportEntry(dst, entry); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return
+}
+
+func exportEntries(cache Cache, prefix string, fn func(entry *DumpEntry) error) error {
+	var err error
+	scanErr := Scan(cache, prefix, func(key string) bool {
+		var entry *DumpEntry
+		entry, err = ExportEntry(cache, key)
+		switch {
+		case err == nil:
+			err = fn(entry)
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotSupported):
+			err = nil
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return scanErr
+}
+
+// ExportEntry reads a single key. Its type is detected by trying to read it
+// as a string first, then as a list and as a set. Sets return ErrNotSupported
+// if the cache doesn't implement SetMemberLister.
+func ExportEntry(cache Cache, key string) (*DumpEntry, error) {
+	entry := &DumpEntry{Key: key, Type: DumpString}
+	var err error
+	entry.Value, err = cache.Get(key)
+	if ext, ok := cache.(ExtendedCache); ok && errors.Is(err, ErrWrongType) {
+		entry.Type = DumpList
+		entry.Values, err = ext.LRange(key, 0, -1)
+		if errors.Is(err, ErrWrongType) {
+			entry.Type = DumpSet
+			entry.Values, err = SMembers(ext, key)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := cache.GetTTL(key)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		// don't round a short TTL down to no TTL
+		entry.TTL = max(ttl.Milliseconds(), 1)
+	}
+	return entry, nil
+}
+
+// ImportEntry writes a single key, overwriting it if it already exists
+func ImportEntry(cache Cache, entry *DumpEntry) error {
+	ttl := time.Duration(entry.TTL) * time.Millisecond
+	if entry.Type == DumpString {
+		return cache.Set(entry.Key, entry.Value, ttl)
+	}
+	if entry.Type != DumpList && entry.Type != DumpSet {
+		return ErrWrongType
+	}
+
+	ext, ok := cache.(ExtendedCache)
+	if !ok {
+		return ErrNotSupported
+	}
+	if err := ext.Del(entry.Key); err != nil {
+		return err
+	}
+	if len(entry.Values) == 0 {
+		return nil
+	}
+	var err error
+	if entry.Type == DumpList {
+		err = ext.RPush(entry.Key, entry.Values...)
+	} else {
+		err = ext.SAdd(entry.Key, entry.Values...)
+	}
+	if err != nil || ttl == 0 {
+		return err
+	}
+	return ext.SetTTL(entry.Key, ttl)
+}
@@ -0,0 +1,160 @@
+package razcache_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+)
+
+func TestExportImport(t *testing.T) {
+	src := inmem.NewInMemExtendedCache()
+	defer src.Close()
+
+	require.NoError(t, src.Set("app:str", "value", time.Hour))
+	require.NoError(t, src.RPush("app:list", "1", "2", "3"))
+	require.NoError(t, src.SAdd("app:set", "a", "b"))
+	_, err := src.Incr("app:counter", 5)
+	require.NoError(t, err)
+	require.NoError(t, src.Set("other", "value", 0))
+
+	var buf bytes.Buffer
+	count, err := Export(src, "app:", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	dst := inmem.NewInMemExtendedCache()
+	defer dst.Close()
+	require.NoError(t, dst.RPush("app:list", "old")) // should be overwritten
+	count, err = Import(dst, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	value, err := dst.Get("app:str")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	ttl, err := dst.GetTTL("app:str")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+	list, err := dst.LRange("app:list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, list)
+	members, err := SMembers(dst, "app:set")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, members)
+	counter, err := dst.Incr("app:counter", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), counter)
+	_, err = dst.Get("other")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestImportErrors(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	// lists and sets require an extended cache
+	count, err := Import(cache, strings.NewReader(`{"key":"a","type":"string","value":"1"}
+{"key":"b","type":"list","values":["1"]}`))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, ErrNotSupported, err)
+
+	_, err = Import(cache, strings.NewReader(`{"key":"a","type":"hash"}`))
+	assert.Equal(t, ErrWrongType, err)
+
+	// caches without scanning support can't be exported
+	_, err = Export(unavailableCache{}, "", new(bytes.Buffer))
+	assert.Equal(t, ErrNotSupported, err)
+}
+
+func TestMigrate(t *testing.T) {
+	src := inmem.NewInMemExtendedCache()
+	defer src.Close()
+	dst := inmem.NewInMemExtendedCache()
+	defer dst.Close()
+
+	require.NoError(t, src.Set("a", "1", 0))
+	require.NoError(t, src.LPush("b", "1", "2"))
+
+	// migrating into a prefixed cache
+	count, err := Migrate(dst.SubExtendedCache("migrated:"), src, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	keys, err := ScanKeys(dst, "migrated:")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"migrated:a", "migrated:b"}, keys)
+	list, err := dst.LRange("migrated:b", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, list)
+}
+
+func TestExportImportBinary(t *testing.T) {
+	src := inmem.NewInMemExtendedCache()
+	defer src.Close()
+
+	binary := string([]byte{0xff, 0x00, 0xfe, 'a'})
+	require.NoError(t, src.Set("bin:"+binary, binary, 0))
+	require.NoError(t, src.RPush("bin:list", "text", binary))
+	require.NoError(t, src.Set("bin:text", "héllo", 0))
+
+	var buf bytes.Buffer
+	count, err := Export(src, "bin:", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Contains(t, buf.String(), `{"key":"bin:text","type":"string","value":"héllo"}`)
+	assert.Equal(t, 2, strings.Count(buf.String(), `"encoding":"base64"`))
+
+	dst := inmem.NewInMemExtendedCache()
+	defer dst.Close()
+	count, err = Import(dst, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	value, err := dst.Get("bin:" + binary)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, value)
+	list, err := dst.LRange("bin:list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"text", binary}, list)
+	value, err = dst.Get("bin:text")
+	assert.NoError(t, err)
+	assert.Equal(t, "héllo", value)
+
+	_, err = Import(dst, strings.NewReader(`{"key":"a","type":"string","encoding":"hex"}`))
+	assert.Error(t, err)
+	_, err = Import(dst, strings.NewReader(`{"key":"!","type":"string","encoding":"base64"}`))
+	assert.Error(t, err)
+}
+
+// scanOnlyCache hides the optional interfaces of the cache except Scanner
+type scanOnlyCache struct {
+	ExtendedCache
+}
+
+func (c scanOnlyCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.ExtendedCache, prefix, fn)
+}
+
+func TestExportSkipsUnlistableSets(t *testing.T) {
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+
+	require.NoError(t, cache.Set("str", "value", 0))
+	require.NoError(t, cache.SAdd("set", "a"))
+
+	_, err := ExportEntry(scanOnlyCache{cache}, "set")
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	var buf bytes.Buffer
+	count, err := Export(scanOnlyCache{cache}, "", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, `{"key":"str","type":"string","value":"value"}`+"\n", buf.String())
+}
@@ -0,0 +1,304 @@
+package razcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const encryptionVersion byte = 1
+
+// EncryptionKey is an AES key (16, 24 or 32 bytes long) identified by ID.
+// The ID is stored in the header of every encrypted value, so values written
+// with an older key can still be decrypted after rotation.
+type EncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+type EncryptionOptions struct {
+	// Keys used for decryption. The first key is used for encryption too.
+	Keys []EncryptionKey
+	// If not empty, cache keys are replaced by their HMAC-SHA256 digest.
+	KeyHashSecret []byte
+	// If true, extended caches support Incr by keeping counters as plain integers,
+	// and they read plain integers as values too. These aren't authenticated, so anyone
+	// with write access to the underlying cache can replace any value with an integer.
+	// Otherwise Incr returns ErrNotSupported.
+	PlainCounters bool
+}
+
+type cipherKey struct {
+	id      string
+	aead    cipher.AEAD
+	sivHash []byte
+}
+
+type cipherSuite struct {
+	primary       *cipherKey
+	keys          map[string]*cipherKey
+	hashSecret    []byte
+	plainCounters bool
+}
+
+func newCipherSuite(opts EncryptionOptions) (*cipherSuite, error) {
+	if len(opts.Keys) == 0 {
+		return nil, errors.New("no encryption keys")
+	}
+	suite := &cipherSuite{
+		keys:          make(map[string]*cipherKey, len(opts.Keys)),
+		hashSecret:    opts.KeyHashSecret,
+		plainCounters: opts.PlainCounters,
+	}
+	for _, key := range opts.Keys {
+		if len(key.ID) > 255 {
+			return nil, errors.New("encryption key ID is too long")
+		}
+		if _, ok := suite.keys[key.ID]; ok {
+			return nil, errors.New("duplicate encryption key ID: " + key.ID)
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		ck := &cipherKey{
+			id:      key.ID,
+			aead:    aead,
+			sivHash: hmacSum(key.Key, []byte("razcache-siv")),
+		}
+		suite.keys[key.ID] = ck
+		if suite.primary == nil {
+			suite.primary = ck
+		}
+	}
+	return suite, nil
+}
+
+func (s *cipherSuite) hashesKeys() bool {
+	return len(s.hashSecret) > 0
+}
+
+func (s *cipherSuite) hashKey(key string) string {
+	if !s.hashesKeys() {
+		return key
+	}
+	return hex.EncodeToString(hmacSum(s.hashSecret, []byte(key)))
+}
+
+// encrypt seals the value using a random nonce and the key as additional data
+func (s *cipherSuite) encrypt(key, value string) (string, error) {
+	nonce := make([]byte, s.primary.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return s.seal(s.primary, nonce, key, value), nil
+}
+
+// encryptDeterministic derives the nonce from the key and value (SIV style),
+// so equal values produce equal ciphertexts. This is used for set members.
+func (s *cipherSuite) encryptDeterministic(ck *cipherKey, key, value string) string {
+	mac := hmac.New(sha256.New, ck.sivHash)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	nonce := mac.Sum(nil)[:ck.aead.NonceSize()]
+	return s.seal(ck, nonce, key, value)
+}
+
+// encryptMember returns the ciphertexts of a set member under every known key
+func (s *cipherSuite) encryptMember(key, value string) []string {
+	results := make([]string, 0, len(s.keys))
+	results = append(results, s.encryptDeterministic(s.primary, key, value))
+	for _, ck := range s.keys {
+		if ck != s.primary {
+			results = append(results, s.encryptDeterministic(ck, key, value))
+		}
+	}
+	return results
+}
+
+func (s *cipherSuite) seal(ck *cipherKey, nonce []byte, key, value string) string {
+	buf := make([]byte, 0, 2+len(ck.id)+len(nonce)+len(value)+ck.aead.Overhead())
+	buf = append(buf, encryptionVersion, byte(len(ck.id)))
+	buf = append(buf, ck.id...)
+	buf = append(buf, nonce...)
+	buf = ck.aead.Seal(buf, nonce, []byte(value), []byte(key))
+	return string(buf)
+}
+
+func (s *cipherSuite) decrypt(key, value string) (string, error) {
+	if len(value) < 2 || value[0] != encryptionVersion {
+		return "", ErrDecryptionFailed
+	}
+	idLen := int(value[1])
+	if len(value) < 2+idLen {
+		return "", ErrDecryptionFailed
+	}
+	ck, ok := s.keys[value[2:2+idLen]]
+	if !ok {
+		return "", ErrDecryptionFailed
+	}
+	raw := value[2+idLen:]
+	nonceSize := ck.aead.NonceSize()
+	if len(raw) < nonceSize {
+		return "", ErrDecryptionFailed
+	}
+	plain, err := ck.aead.Open(nil, []byte(raw[:nonceSize]), []byte(raw[nonceSize:]), []byte(key))
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+	return string(plain), nil
+}
+
+// decryptValue is like decrypt, but with PlainCounters it lets plain integers through,
+// because counters modified by Incr cannot be stored encrypted. Only extended caches use it.
+func (s *cipherSuite) decryptValue(key, value string) (string, error) {
+	if s.plainCounters && len(value) > 0 && value[0] != encryptionVersion {
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return value, nil
+		}
+	}
+	return s.decrypt(key, value)
+}
+
+func (s *cipherSuite) decryptAll(key string, values []string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	results := make([]string, len(values))
+	for i, value := range values {
+		plain, err := s.decrypt(key, value)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = plain
+	}
+	return results, nil
+}
+
+func (s *cipherSuite) encryptAll(key string, values []string) ([]string, error) {
+	results := make([]string, len(values))
+	for i, value := range values {
+		encrypted, err := s.encrypt(key, value)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = encrypted
+	}
+	return results, nil
+}
+
+func hmacSum(secret, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+type encryptedCache struct {
+	cache Cache
+	suite *cipherSuite
+}
+
+// NewEncryptedCache returns a cache that encrypts values with AES-GCM before
+// passing them to the underlying cache
+func NewEncryptedCache(cache Cache, opts EncryptionOptions) (Cache, error) {
+	suite, err := newCipherSuite(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedCache{
+		cache: cache,
+		suite: suite,
+	}, nil
+}
+
+func (c *encryptedCache) Set(key, value string, ttl time.Duration) error {
+	encrypted, err := c.suite.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(c.suite.hashKey(key), encrypted, ttl)
+}
+
+func (c *encryptedCache) Get(key string) (string, error) {
+	value, err := c.cache.Get(c.suite.hashKey(key))
+	if err != nil {
+		return "", err
+	}
+	return c.suite.decrypt(key, value)
+}
+
+func (c *encryptedCache) Del(key string) error {
+	return c.cache.Del(c.suite.hashKey(key))
+}
+
+func (c *encryptedCache) GetTTL(key string) (time.Duration, error) {
+	return c.cache.GetTTL(c.suite.hashKey(key))
+}
+
+func (c *encryptedCache) SetTTL(key string, ttl time.Duration) error {
+	return c.cache.SetTTL(c.suite.hashKey(key), ttl)
+}
+
+func (c *encryptedCache) GetEx(key string, ttl time.Duration) (string, error) {
+	value, err := GetEx(c.cache, c.suite.hashKey(key), ttl)
+	if err != nil {
+		return "", err
+	}
+	return c.suite.decrypt(key, value)
+}
+
+func (c *encryptedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	return Expire(c.cache, c.suite.hashKey(key), ttl, cond)
+}
+
+func (c *encryptedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	return ExpireAt(c.cache, c.suite.hashKey(key), deadline, cond)
+}
+
+func (c *encryptedCache) Persist(key string) error {
+	return Persist(c.cache, c.suite.hashKey(key))
+}
+
+func (c *encryptedCache) SetKeepTTL(key, value string) error {
+	encrypted, err := c.suite.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return SetKeepTTL(c.cache, c.suite.hashKey(key), encrypted)
+}
+
+// Scan returns ErrNotSupported if keys are hashed, as they can't be recovered
+func (c *encryptedCache) Scan(prefix string, fn func(key string) bool) error {
+	if c.suite.hashesKeys() {
+		return ErrNotSupported
+	}
+	return Scan(c.cache, prefix, fn)
+}
+
+// Capabilities doesn't include CapBytes, as values are encrypted as strings
+func (c *encryptedCache) Capabilities() Capability {
+	caps := Capabilities(c.cache) & (CapScan | CapExpire | CapGetEx)
+	if c.suite.hashesKeys() {
+		caps &^= CapScan
+	}
+	return caps
+}
+
+func (c *encryptedCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *encryptedCache) Close() error {
+	return c.cache.Close()
+}
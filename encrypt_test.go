@@ -0,0 +1,112 @@
+package razcache_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+var (
+	testKey1 = EncryptionKey{ID: "key1", Key: []byte("0123456789abcdef0123456789abcdef")}
+	testKey2 = EncryptionKey{ID: "key2", Key: []byte("fedcba9876543210fedcba9876543210")}
+)
+
+func TestEncryptedCache(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	encCache, err := NewEncryptedCache(cache, EncryptionOptions{Keys: []EncryptionKey{testKey1}})
+	require.NoError(t, err)
+	testutil.TestBasic(t, encCache)
+
+	// underlying cache should only see the encrypted value
+	assert.NoError(t, encCache.Set("secret", "plaintext", 0))
+	raw, err := cache.Get("secret")
+	assert.NoError(t, err)
+	assert.NotContains(t, raw, "plaintext")
+
+	// values should be bound to their keys
+	assert.NoError(t, cache.Set("other", raw, 0))
+	_, err = encCache.Get("other")
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// plain integers aren't let through without counters
+	assert.NoError(t, cache.Set("int", "42", 0))
+	_, err = encCache.Get("int")
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+func TestEncryptedCacheKeyRotation(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	oldCache, err := NewEncryptedCache(cache, EncryptionOptions{Keys: []EncryptionKey{testKey1}})
+	require.NoError(t, err)
+	assert.NoError(t, oldCache.Set("a", "val_a", 0))
+
+	// new primary key should still be able to decrypt values of the old key
+	newCache, err := NewEncryptedCache(cache, EncryptionOptions{Keys: []EncryptionKey{testKey2, testKey1}})
+	require.NoError(t, err)
+	value, err := newCache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+
+	// unknown keys should fail decryption
+	assert.NoError(t, newCache.Set("b", "val_b", 0))
+	_, err = oldCache.Get("b")
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+func TestEncryptedCacheKeyHashing(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	encCache, err := NewEncryptedCache(cache, EncryptionOptions{
+		Keys:          []EncryptionKey{testKey1},
+		KeyHashSecret: []byte("hash secret"),
+	})
+	require.NoError(t, err)
+
+	// original key should not be visible in underlying cache
+	assert.NoError(t, encCache.Set("user:1", "value", 0))
+	_, err = cache.Get("user:1")
+	assert.Equal(t, ErrNotFound, err)
+
+	// prefixed sub caches should work on top of hashed keys
+	subcache := encCache.SubCache("prefix:")
+	assert.NoError(t, subcache.Set("a", "val_a", 0))
+	value, err := encCache.Get("prefix:a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+}
+
+func TestEncryptedCacheOverPrefixCache(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	encCache, err := NewEncryptedCache(NewPrefixCache(cache, "prefix:"), EncryptionOptions{Keys: []EncryptionKey{testKey1}})
+	require.NoError(t, err)
+	testutil.TestBasic(t, encCache)
+
+	raw, err := cache.Get("prefix:key")
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(raw, "value2"))
+}
+
+func TestEncryptedCacheInvalidOptions(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	_, err := NewEncryptedCache(cache, EncryptionOptions{})
+	assert.Error(t, err)
+	_, err = NewEncryptedCache(cache, EncryptionOptions{Keys: []EncryptionKey{{ID: "short", Key: []byte("short")}}})
+	assert.Error(t, err)
+	_, err = NewEncryptedCache(cache, EncryptionOptions{Keys: []EncryptionKey{testKey1, testKey1}})
+	assert.Error(t, err)
+}
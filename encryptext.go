@@ -0,0 +1,247 @@
+package razcache
+
+import (
+	"slices"
+	"time"
+)
+
+type encryptedExtCache struct {
+	cache ExtendedCache
+	suite *cipherSuite
+}
+
+// NewEncryptedExtendedCache is like NewEncryptedCache, but it also encrypts
+// list elements and set members. Counters are only supported with PlainCounters,
+// as they are stored unencrypted and unauthenticated.
+func NewEncryptedExtendedCache(cache ExtendedCache, opts EncryptionOptions) (ExtendedCache, error) {
+	suite, err := newCipherSuite(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedExtCache{
+		cache: cache,
+		suite: suite,
+	}, nil
+}
+
+func (c *encryptedExtCache) Set(key, value string, ttl time.Duration) error {
+	encrypted, err := c.suite.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(c.suite.hashKey(key), encrypted, ttl)
+}
+
+func (c *encryptedExtCache) Get(key string) (string, error) {
+	value, err := c.cache.Get(c.suite.hashKey(key))
+	if err != nil {
+		return "", err
+	}
+	return c.suite.decryptValue(key, value)
+}
+
+func (c *encryptedExtCache) Del(key string) error {
+	return c.cache.Del(c.suite.hashKey(key))
+}
+
+func (c *encryptedExtCache) GetTTL(key string) (time.Duration, error) {
+	return c.cache.GetTTL(c.suite.hashKey(key))
+}
+
+func (c *encryptedExtCache) SetTTL(key string, ttl time.Duration) error {
+	return c.cache.SetTTL(c.suite.hashKey(key), ttl)
+}
+
+func (c *encryptedExtCache) LPush(key string, values ...string) error {
+	encrypted, err := c.suite.encryptAll(key, values)
+	if err != nil {
+		return err
+	}
+	return c.cache.LPush(c.suite.hashKey(key), encrypted...)
+}
+
+func (c *encryptedExtCache) RPush(key string, values ...string) error {
+	encrypted, err := c.suite.encryptAll(key, values)
+	if err != nil {
+		return err
+	}
+	return c.cache.RPush(c.suite.hashKey(key), encrypted...)
+}
+
+func (c *encryptedExtCache) LPop(key string, count int) ([]string, error) {
+	values, err := c.cache.LPop(c.suite.hashKey(key), count)
+	if err != nil {
+		return nil, err
+	}
+	return c.suite.decryptAll(key, values)
+}
+
+func (c *encryptedExtCache) RPop(key string, count int) ([]string, error) {
+	values, err := c.cache.RPop(c.suite.hashKey(key), count)
+	if err != nil {
+		return nil, err
+	}
+	return c.suite.decryptAll(key, values)
+}
+
+func (c *encryptedExtCache) LLen(key string) (int, error) {
+	return c.cache.LLen(c.suite.hashKey(key))
+}
+
+func (c *encryptedExtCache) LRange(key string, start, stop int) ([]string, error) {
+	values, err := c.cache.LRange(c.suite.hashKey(key), start, stop)
+	if err != nil {
+		return nil, err
+	}
+	return c.suite.decryptAll(key, values)
+}
+
+// SAdd adds the members encrypted with the primary key, then removes the ones encrypted
+// with older keys, so members added again after key rotation aren't stored twice
+func (c *encryptedExtCache) SAdd(key string, values ...string) error {
+	hashedKey := c.suite.hashKey(key)
+	encrypted, outdated := c.encryptNewMembers(key, values)
+	if err := c.cache.SAdd(hashedKey, encrypted...); err != nil || len(outdated) == 0 {
+		return err
+	}
+	return c.cache.SRem(hashedKey, outdated...)
+}
+
+// SAddCount doesn't count members that were only encrypted with an outdated key as added
+func (c *encryptedExtCache) SAddCount(key string, values ...string) (int, error) {
+	hashedKey := c.suite.hashKey(key)
+	encrypted, outdated := c.encryptNewMembers(key, values)
+	added, err := SAddCount(c.cache, hashedKey, encrypted...)
+	if err != nil || len(outdated) == 0 {
+		return added, err
+	}
+	removed, err := SRemCount(c.cache, hashedKey, outdated...)
+	return max(added-removed, 0), err
+}
+
+// encryptNewMembers returns the members encrypted with the current key,
+// and the ones encrypted with outdated keys that they replace
+func (c *encryptedExtCache) encryptNewMembers(key string, values []string) (encrypted, outdated []string) {
+	encrypted = make([]string, len(values))
+	for i, value := range values {
+		members := c.suite.encryptMember(key, value)
+		encrypted[i] = members[0]
+		outdated = append(outdated, members[1:]...)
+	}
+	return
+}
+
+func (c *encryptedExtCache) SRem(key string, values ...string) error {
+	return c.cache.SRem(c.suite.hashKey(key), c.encryptMembers(key, values)...)
+}
+
+func (c *encryptedExtCache) SRemCount(key string, values ...string) (int, error) {
+	return SRemCount(c.cache, c.suite.hashKey(key), c.encryptMembers(key, values)...)
+}
+
+// encryptMembers returns the members encrypted with every key, so outdated ones are matched too
+func (c *encryptedExtCache) encryptMembers(key string, values []string) []string {
+	encrypted := make([]string, 0, len(values)*len(c.suite.keys))
+	for _, value := range values {
+		encrypted = append(encrypted, c.suite.encryptMember(key, value)...)
+	}
+	return encrypted
+}
+
+func (c *encryptedExtCache) SHas(key, value string) (bool, error) {
+	hashedKey := c.suite.hashKey(key)
+	for _, encrypted := range c.suite.encryptMember(key, value) {
+		found, err := c.cache.SHas(hashedKey, encrypted)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+func (c *encryptedExtCache) SLen(key string) (int, error) {
+	return c.cache.SLen(c.suite.hashKey(key))
+}
+
+// SMembers decrypts the members, which might be stored under multiple keys after key rotation
+func (c *encryptedExtCache) SMembers(key string) ([]string, error) {
+	values, err := SMembers(c.cache, c.suite.hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+	members, err := c.suite.decryptAll(key, values)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(members)
+	return slices.Compact(members), nil
+}
+
+// Incr returns ErrNotSupported without PlainCounters
+func (c *encryptedExtCache) Incr(key string, increment int64) (int64, error) {
+	if !c.suite.plainCounters {
+		return 0, ErrNotSupported
+	}
+	return c.cache.Incr(c.suite.hashKey(key), increment)
+}
+
+func (c *encryptedExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	value, err := GetEx(c.cache, c.suite.hashKey(key), ttl)
+	if err != nil {
+		return "", err
+	}
+	return c.suite.decryptValue(key, value)
+}
+
+func (c *encryptedExtCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	return Expire(c.cache, c.suite.hashKey(key), ttl, cond)
+}
+
+func (c *encryptedExtCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	return ExpireAt(c.cache, c.suite.hashKey(key), deadline, cond)
+}
+
+func (c *encryptedExtCache) Persist(key string) error {
+	return Persist(c.cache, c.suite.hashKey(key))
+}
+
+func (c *encryptedExtCache) SetKeepTTL(key, value string) error {
+	encrypted, err := c.suite.encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return SetKeepTTL(c.cache, c.suite.hashKey(key), encrypted)
+}
+
+// Scan returns ErrNotSupported if keys are hashed, as they can't be recovered
+func (c *encryptedExtCache) Scan(prefix string, fn func(key string) bool) error {
+	if c.suite.hashesKeys() {
+		return ErrNotSupported
+	}
+	return Scan(c.cache, prefix, fn)
+}
+
+// Capabilities doesn't include CapBytes, as values are encrypted as strings,
+// and it only includes CapCounters with PlainCounters
+func (c *encryptedExtCache) Capabilities() Capability {
+	caps := Capabilities(c.cache) & (CapExtended | CapSetMembers | CapSetCount | CapScan | CapExpire | CapGetEx)
+	if c.suite.hashesKeys() {
+		caps &^= CapScan
+	}
+	if !c.suite.plainCounters {
+		caps &^= CapCounters
+	}
+	return caps
+}
+
+func (c *encryptedExtCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *encryptedExtCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *encryptedExtCache) Close() error {
+	return c.cache.Close()
+}
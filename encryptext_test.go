@@ -0,0 +1,115 @@
+package razcache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func newEncryptedExtendedCache(t *testing.T, cache ExtendedCache, keys ...EncryptionKey) ExtendedCache {
+	encCache, err := NewEncryptedExtendedCache(cache, EncryptionOptions{
+		Keys:          keys,
+		KeyHashSecret: []byte("hash secret"),
+	})
+	require.NoError(t, err)
+	return encCache
+}
+
+func newEncryptedCounterCache(t *testing.T, cache ExtendedCache) ExtendedCache {
+	encCache, err := NewEncryptedExtendedCache(cache, EncryptionOptions{
+		Keys:          []EncryptionKey{testKey1},
+		KeyHashSecret: []byte("hash secret"),
+		PlainCounters: true,
+	})
+	require.NoError(t, err)
+	return encCache
+}
+
+func TestEncryptedExtendedCache(t *testing.T) {
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+
+	encCache := newEncryptedExtendedCache(t, cache, testKey1)
+	testutil.TestBasic(t, encCache)
+	testutil.TestLists(t, encCache)
+	testutil.TestSets(t, encCache)
+}
+
+func TestEncryptedExtendedCacheSets(t *testing.T) {
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+
+	oldCache := newEncryptedExtendedCache(t, cache, testKey1)
+	assert.NoError(t, oldCache.SAdd("set", "a", "b"))
+
+	// members added with the old key should be found after rotation
+	newCache := newEncryptedExtendedCache(t, cache, testKey2, testKey1)
+	assert.NoError(t, newCache.SAdd("set", "c"))
+	for _, member := range []string{"a", "b", "c"} {
+		found, err := newCache.SHas("set", member)
+		assert.NoError(t, err)
+		assert.True(t, found)
+	}
+	found, err := newCache.SHas("set", "d")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, newCache.SRem("set", "a", "c"))
+	slen, err := newCache.SLen("set")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, slen)
+
+	// adding a member again after rotation should replace its old ciphertext
+	assert.NoError(t, newCache.SAdd("set", "b"))
+	slen, err = newCache.SLen("set")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, slen)
+	members, err := SMembers(newCache, "set")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, members)
+	found, err = oldCache.SHas("set", "b")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestEncryptedExtendedCacheIncr(t *testing.T) {
+	cache := inmem.NewInMemExtendedCache()
+	defer cache.Close()
+
+	encCache := newEncryptedCounterCache(t, cache)
+	assert.True(t, Capabilities(encCache).Has(CapCounters))
+
+	// counters are stored unencrypted, but should be readable
+	value, err := encCache.Incr("counter", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+	str, err := encCache.Get("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "5", str)
+
+	// encrypted values cannot be incremented
+	assert.NoError(t, encCache.Set("int", "2", 0))
+	_, err = encCache.Incr("int", 1)
+	assert.Equal(t, ErrWrongType, err)
+
+	// without PlainCounters, counters aren't supported and plain integers aren't accepted as values
+	strictCache := newEncryptedExtendedCache(t, cache, testKey1)
+	assert.False(t, Capabilities(strictCache).Has(CapCounters))
+	_, err = strictCache.Incr("counter", 1)
+	assert.Equal(t, ErrNotSupported, err)
+	_, err = strictCache.Get("counter")
+	assert.Equal(t, ErrDecryptionFailed, err)
+	_, err = GetEx(strictCache, "counter", 0)
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+func TestEncryptedExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		return newEncryptedCounterCache(t, inmem.NewInMemExtendedCache())
+	}, testutil.WithSkip("encrypted strings cannot be incremented", "Incr", "IncrEdgeCases"))
+}
@@ -2,9 +2,63 @@ package razcache
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 )
 
 var (
-	ErrNotFound  = errors.New("not found")
-	ErrWrongType = errors.New("wrong type")
+	ErrNotFound         = errors.New("not found")
+	ErrWrongType        = errors.New("wrong type")
+	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrNoShards         = errors.New("no shards")
+	ErrCircuitOpen      = errors.New("circuit breaker is open")
+	ErrTimeout          = errors.New("timeout")
+	ErrNotSupported     = errors.New("not supported")
+	ErrCacheClosed      = errors.New("cache is closed")
+	ErrUnavailable      = errors.New("unavailable")
+	ErrConflict         = errors.New("conflict")
+	ErrValueTooLarge    = errors.New("value too large")
 )
+
+// Error is a failure of a backend operation. ErrNotFound and ErrWrongType aren't failures,
+// so backends return them as they are. errors.Is matches both Kind and the error of the driver.
+type Error struct {
+	Op      string // name of the method, like "Get"
+	Key     string // key as the backend received it, including the prefixes of sub caches
+	Backend string // like "redis" or "badger"
+	Kind    error  // one of the sentinel errors like ErrTimeout, or nil if it's unknown
+	Err     error  // error of the driver
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Backend)
+	sb.WriteByte(' ')
+	sb.WriteString(e.Op)
+	if len(e.Key) > 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.Quote(e.Key))
+	}
+	sb.WriteString(": ")
+	if e.Kind != nil && e.Kind != e.Err {
+		sb.WriteString(e.Kind.Error())
+		if e.Err != nil {
+			sb.WriteString(": ")
+		}
+	}
+	if e.Err != nil {
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil && e.Err != e.Kind {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
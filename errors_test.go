@@ -0,0 +1,34 @@
+package razcache_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	err := error(&Error{Op: "Get", Key: "prefix:key", Backend: "redis", Kind: ErrUnavailable, Err: cause})
+
+	assert.Equal(t, `redis Get "prefix:key": unavailable: dial tcp: connection refused`, err.Error())
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrTimeout)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	var cacheErr *Error
+	assert.ErrorAs(t, err, &cacheErr)
+	assert.Equal(t, "Get", cacheErr.Op)
+	assert.Equal(t, "prefix:key", cacheErr.Key)
+
+	// errors of unknown kind and errors without a key
+	err = &Error{Op: "Scan", Backend: "badger", Err: cause}
+	assert.Equal(t, "badger Scan: dial tcp: connection refused", err.Error())
+	assert.ErrorIs(t, err, cause)
+	err = &Error{Op: "Close", Backend: "badger", Kind: ErrCacheClosed, Err: ErrCacheClosed}
+	assert.Equal(t, "badger Close: cache is closed", err.Error())
+	assert.ErrorIs(t, err, ErrCacheClosed)
+}
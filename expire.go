@@ -0,0 +1,95 @@
+package razcache
+
+import (
+	"time"
+)
+
+// ExpireCondition restricts when Expire and ExpireAt change the expiration of a key,
+// like the NX, XX, GT and LT options of the Redis EXPIRE command
+type ExpireCondition int
+
+const (
+	ExpireAlways ExpireCondition = iota
+	ExpireNX                     // only if the key has no expiration
+	ExpireXX                     // only if the key has an expiration
+	ExpireGT                     // only if the new expiration is later, keys without expiration never expire later
+	ExpireLT                     // only if the new expiration is earlier, keys without expiration always expire earlier
+)
+
+// Allows reports if the condition allows replacing the current expiration of a key
+// (the zero time if it has none) with the new deadline
+func (cond ExpireCondition) Allows(current, deadline time.Time) bool {
+	switch cond {
+	case ExpireNX:
+		return current.IsZero()
+	case ExpireXX:
+		return !current.IsZero()
+	case ExpireGT:
+		return !current.IsZero() && deadline.After(current)
+	case ExpireLT:
+		return current.IsZero() || deadline.Before(current)
+	default:
+		return true
+	}
+}
+
+func (cond ExpireCondition) String() string {
+	switch cond {
+	case ExpireNX:
+		return "NX"
+	case ExpireXX:
+		return "XX"
+	case ExpireGT:
+		return "GT"
+	case ExpireLT:
+		return "LT"
+	default:
+		return ""
+	}
+}
+
+// Expirer is implemented by caches with finer control over expiration.
+// Expire and ExpireAt report if the condition allowed the change, and they delete the key
+// if the new expiration isn't in the future. All of them return ErrNotFound for missing keys,
+// except SetKeepTTL, which sets the value of a missing key without TTL.
+type Expirer interface {
+	Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error)
+	ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error)
+	Persist(key string) error
+	SetKeepTTL(key, value string) error
+}
+
+// Expire changes the TTL of a key if the cache implements Expirer,
+// otherwise it returns ErrNotSupported
+func Expire(cache Cache, key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	if expirer, ok := cache.(Expirer); ok {
+		return expirer.Expire(key, ttl, cond)
+	}
+	return false, ErrNotSupported
+}
+
+// ExpireAt changes the deadline of a key if the cache implements Expirer,
+// otherwise it returns ErrNotSupported
+func ExpireAt(cache Cache, key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	if expirer, ok := cache.(Expirer); ok {
+		return expirer.ExpireAt(key, deadline, cond)
+	}
+	return false, ErrNotSupported
+}
+
+// Persist removes the TTL of a key. Caches that don't implement Expirer fall back to SetTTL.
+func Persist(cache Cache, key string) error {
+	if expirer, ok := cache.(Expirer); ok {
+		return expirer.Persist(key)
+	}
+	return cache.SetTTL(key, 0)
+}
+
+// SetKeepTTL sets the value of a key without changing its TTL if the cache implements Expirer,
+// otherwise it returns ErrNotSupported
+func SetKeepTTL(cache Cache, key, value string) error {
+	if expirer, ok := cache.(Expirer); ok {
+		return expirer.SetKeepTTL(key, value)
+	}
+	return ErrNotSupported
+}
@@ -0,0 +1,27 @@
+package razcache
+
+import (
+	"time"
+)
+
+// Toucher is implemented by caches that can read a key and change its TTL at once,
+// like the Redis GETEX command. The TTL follows the same rules as SetTTL.
+type Toucher interface {
+	GetEx(key string, ttl time.Duration) (string, error)
+}
+
+// GetEx reads a key and changes its TTL. Caches that don't implement Toucher
+// fall back to Get followed by SetTTL.
+func GetEx(cache Cache, key string, ttl time.Duration) (string, error) {
+	if toucher, ok := cache.(Toucher); ok {
+		return toucher.GetEx(key, ttl)
+	}
+	value, err := cache.Get(key)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.SetTTL(key, ttl); err != nil {
+		return "", err
+	}
+	return value, nil
+}
@@ -0,0 +1,124 @@
+package razcache
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const defaultVirtualNodes = 128
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+type ringNode[T any] struct {
+	value  T
+	weight int
+}
+
+// hashRing is a consistent hash ring where each node is represented by
+// weight*virtualNodes points, so adding or removing a node only moves the
+// keys that belong to its points
+type hashRing[T any] struct {
+	mu           sync.RWMutex
+	virtualNodes int
+	nodes        map[string]ringNode[T]
+	points       []ringPoint
+}
+
+func (r *hashRing[T]) init(virtualNodes int) {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+	r.virtualNodes = virtualNodes
+	r.nodes = make(map[string]ringNode[T])
+}
+
+func (r *hashRing[T]) add(name string, value T, weight int) error {
+	if weight <= 0 {
+		return errors.New("shard weight must be positive")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.nodes[name]; ok {
+		return errors.New("shard already exists: " + name)
+	}
+	r.nodes[name] = ringNode[T]{value: value, weight: weight}
+	for i := 0; i < weight*r.virtualNodes; i++ {
+		r.points = append(r.points, ringPoint{
+			hash: hashString(name + "#" + strconv.Itoa(i)),
+			node: name,
+		})
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].node < r.points[j].node
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return nil
+}
+
+func (r *hashRing[T]) remove(name string) (value T, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var node ringNode[T]
+	if node, ok = r.nodes[name]; !ok {
+		return
+	}
+	delete(r.nodes, name)
+	points := r.points[:0]
+	for _, point := range r.points {
+		if point.node != name {
+			points = append(points, point)
+		}
+	}
+	r.points = points
+	return node.value, true
+}
+
+func (r *hashRing[T]) get(key string) (name string, value T, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		err = ErrNoShards
+		return
+	}
+	hash := hashString(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	name = r.points[i].node
+	value = r.nodes[name].value
+	return
+}
+
+func (r *hashRing[T]) all() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make([]T, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		values = append(values, node.value)
+	}
+	return values
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// fnv alone distributes similar strings poorly, so mix the bits (splitmix64)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
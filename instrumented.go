@@ -0,0 +1,140 @@
+package razcache
+
+import (
+	"time"
+)
+
+type instrumentedCache struct {
+	cache     Cache
+	collector MetricsCollector
+	labels    MetricsLabels
+}
+
+// NewInstrumentedCache reports the outcome and latency of every operation
+// to the collector. Sub caches report the extended prefix in their labels.
+func NewInstrumentedCache(cache Cache, collector MetricsCollector, labels MetricsLabels) Cache {
+	return &instrumentedCache{
+		cache:     cache,
+		collector: collector,
+		labels:    labels,
+	}
+}
+
+func (c *instrumentedCache) observe(op string, begin time.Time, err error) {
+	c.collector.Observe(op, c.labels, outcomeOf(err), time.Since(begin))
+}
+
+func (c *instrumentedCache) Set(key, value string, ttl time.Duration) error {
+	begin := time.Now()
+	err := c.cache.Set(key, value, ttl)
+	c.observe("Set", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) Get(key string) (string, error) {
+	begin := time.Now()
+	result, err := c.cache.Get(key)
+	c.observe("Get", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) Del(key string) error {
+	begin := time.Now()
+	err := c.cache.Del(key)
+	c.observe("Del", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) GetTTL(key string) (time.Duration, error) {
+	begin := time.Now()
+	result, err := c.cache.GetTTL(key)
+	c.observe("GetTTL", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) SetTTL(key string, ttl time.Duration) error {
+	begin := time.Now()
+	err := c.cache.SetTTL(key, ttl)
+	c.observe("SetTTL", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	begin := time.Now()
+	err := SetBytes(c.cache, key, value, ttl)
+	c.observe("SetBytes", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) GetBytes(key string) ([]byte, error) {
+	begin := time.Now()
+	result, err := GetBytes(c.cache, key)
+	c.observe("GetBytes", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	begin := time.Now()
+	result, err := AppendBytes(c.cache, dst, key)
+	c.observe("AppendBytes", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) GetEx(key string, ttl time.Duration) (string, error) {
+	begin := time.Now()
+	result, err := GetEx(c.cache, key, ttl)
+	c.observe("GetEx", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	begin := time.Now()
+	result, err := Expire(c.cache, key, ttl, cond)
+	c.observe("Expire", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	begin := time.Now()
+	result, err := ExpireAt(c.cache, key, deadline, cond)
+	c.observe("ExpireAt", begin, err)
+	return result, err
+}
+
+func (c *instrumentedCache) Persist(key string) error {
+	begin := time.Now()
+	err := Persist(c.cache, key)
+	c.observe("Persist", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) SetKeepTTL(key, value string) error {
+	begin := time.Now()
+	err := SetKeepTTL(c.cache, key, value)
+	c.observe("SetKeepTTL", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) Scan(prefix string, fn func(key string) bool) error {
+	begin := time.Now()
+	err := Scan(c.cache, prefix, fn)
+	c.observe("Scan", begin, err)
+	return err
+}
+
+func (c *instrumentedCache) Capabilities() Capability {
+	return Capabilities(c.cache) & capOptional
+}
+
+func (c *instrumentedCache) SubCache(prefix string) Cache {
+	labels := c.labels
+	labels.Prefix += prefix
+	return NewInstrumentedCache(c.cache.SubCache(prefix), c.collector, labels)
+}
+
+func (c *instrumentedCache) Close() error {
+	begin := time.Now()
+	err := c.cache.Close()
+	c.observe("Close", begin, err)
+	return err
+}
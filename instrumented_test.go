@@ -0,0 +1,108 @@
+package razcache_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+type observation struct {
+	op      string
+	labels  MetricsLabels
+	outcome Outcome
+}
+
+type recordingCollector struct {
+	observations []observation
+}
+
+func (c *recordingCollector) Observe(op string, labels MetricsLabels, outcome Outcome, latency time.Duration) {
+	c.observations = append(c.observations, observation{op: op, labels: labels, outcome: outcome})
+}
+
+func TestInstrumentedCache(t *testing.T) {
+	collector := new(recordingCollector)
+	labels := MetricsLabels{Backend: "inmem"}
+	cache := NewInstrumentedCache(inmem.NewInMemCache(), collector, labels)
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+	assert.Equal(t, []observation{
+		{op: "Get", labels: labels, outcome: OutcomeMiss},
+		{op: "Set", labels: labels, outcome: OutcomeHit},
+		{op: "Set", labels: labels, outcome: OutcomeHit},
+		{op: "Get", labels: labels, outcome: OutcomeHit},
+	}, collector.observations)
+
+	// sub caches should report their prefix
+	collector.observations = nil
+	subcache := cache.SubCache("prefix:").SubCache("sub:")
+	assert.NoError(t, subcache.Set("a", "val_a", 0))
+	assert.Equal(t, []observation{
+		{op: "Set", labels: MetricsLabels{Backend: "inmem", Prefix: "prefix:sub:"}, outcome: OutcomeHit},
+	}, collector.observations)
+}
+
+func TestInstrumentedCacheWrappedErrors(t *testing.T) {
+	// misses are told apart from errors even if they are wrapped by an inner cache
+	wrapErrors := func(next Handler) Handler {
+		return func(op *Operation) {
+			next(op)
+			if op.Err != nil {
+				op.Err = fmt.Errorf("inner: %w", op.Err)
+			}
+		}
+	}
+	collector := new(recordingCollector)
+	cache := NewInstrumentedCache(NewMiddlewareCache(inmem.NewInMemCache(), wrapErrors), collector, MetricsLabels{})
+	defer cache.Close()
+
+	_, err := cache.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotEqual(t, ErrNotFound, err)
+	assert.Equal(t, []observation{{op: "Get", outcome: OutcomeMiss}}, collector.observations)
+}
+
+func TestStatsCollector(t *testing.T) {
+	collector := NewStatsCollector()
+	cache := NewInstrumentedCache(inmem.NewInMemCache(), collector, MetricsLabels{Backend: "inmem"})
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("a", "val_a", 0))
+	for _, key := range []string{"a", "a", "b"} {
+		cache.Get(key)
+	}
+	collector.Observe("Get", MetricsLabels{Backend: "other"}, OutcomeError, 2*time.Second)
+
+	snapshot := collector.Snapshot()
+	assert.Len(t, snapshot, 3)
+	get := snapshot[0]
+	assert.Equal(t, "inmem", get.Backend)
+	assert.Equal(t, "Get", get.Op)
+	assert.Equal(t, uint64(3), get.Calls)
+	assert.Equal(t, uint64(2), get.Hits)
+	assert.Equal(t, uint64(1), get.Misses)
+	assert.Equal(t, uint64(0), get.Errors)
+	assert.Len(t, get.Buckets, len(LatencyBuckets)+1)
+	assert.Equal(t, "Set", snapshot[1].Op)
+
+	// slow calls should end up in the last bucket
+	other := snapshot[2]
+	assert.Equal(t, "other", other.Backend)
+	assert.Equal(t, uint64(1), other.Errors)
+	assert.Equal(t, uint64(1), other.Buckets[len(LatencyBuckets)])
+
+	// snapshot should be published as JSON through expvar
+	collector.Publish("razcache_test_stats")
+	var published []OpStats
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("razcache_test_stats").String()), &published))
+	assert.Equal(t, snapshot, published)
+}
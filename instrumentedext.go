@@ -0,0 +1,240 @@
+package razcache
+
+import (
+	"time"
+)
+
+type instrumentedExtCache struct {
+	cache     ExtendedCache
+	collector MetricsCollector
+	labels    MetricsLabels
+}
+
+func NewInstrumentedExtendedCache(cache ExtendedCache, collector MetricsCollector, labels MetricsLabels) ExtendedCache {
+	return &instrumentedExtCache{
+		cache:     cache,
+		collector: collector,
+		labels:    labels,
+	}
+}
+
+func (c *instrumentedExtCache) observe(op string, begin time.Time, err error) {
+	c.collector.Observe(op, c.labels, outcomeOf(err), time.Since(begin))
+}
+
+func (c *instrumentedExtCache) Set(key, value string, ttl time.Duration) error {
+	begin := time.Now()
+	err := c.cache.Set(key, value, ttl)
+	c.observe("Set", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) Get(key string) (string, error) {
+	begin := time.Now()
+	result, err := c.cache.Get(key)
+	c.observe("Get", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) Del(key string) error {
+	begin := time.Now()
+	err := c.cache.Del(key)
+	c.observe("Del", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) GetTTL(key string) (time.Duration, error) {
+	begin := time.Now()
+	result, err := c.cache.GetTTL(key)
+	c.observe("GetTTL", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SetTTL(key string, ttl time.Duration) error {
+	begin := time.Now()
+	err := c.cache.SetTTL(key, ttl)
+	c.observe("SetTTL", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) LPush(key string, values ...string) error {
+	begin := time.Now()
+	err := c.cache.LPush(key, values...)
+	c.observe("LPush", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) RPush(key string, values ...string) error {
+	begin := time.Now()
+	err := c.cache.RPush(key, values...)
+	c.observe("RPush", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) LPop(key string, count int) ([]string, error) {
+	begin := time.Now()
+	result, err := c.cache.LPop(key, count)
+	c.observe("LPop", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) RPop(key string, count int) ([]string, error) {
+	begin := time.Now()
+	result, err := c.cache.RPop(key, count)
+	c.observe("RPop", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) LLen(key string) (int, error) {
+	begin := time.Now()
+	result, err := c.cache.LLen(key)
+	c.observe("LLen", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) LRange(key string, start, stop int) ([]string, error) {
+	begin := time.Now()
+	result, err := c.cache.LRange(key, start, stop)
+	c.observe("LRange", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SAdd(key string, values ...string) error {
+	begin := time.Now()
+	err := c.cache.SAdd(key, values...)
+	c.observe("SAdd", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) SRem(key string, values ...string) error {
+	begin := time.Now()
+	err := c.cache.SRem(key, values...)
+	c.observe("SRem", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) SAddCount(key string, values ...string) (int, error) {
+	begin := time.Now()
+	result, err := SAddCount(c.cache, key, values...)
+	c.observe("SAddCount", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SRemCount(key string, values ...string) (int, error) {
+	begin := time.Now()
+	result, err := SRemCount(c.cache, key, values...)
+	c.observe("SRemCount", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SHas(key, value string) (bool, error) {
+	begin := time.Now()
+	result, err := c.cache.SHas(key, value)
+	c.observe("SHas", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SLen(key string) (int, error) {
+	begin := time.Now()
+	result, err := c.cache.SLen(key)
+	c.observe("SLen", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SMembers(key string) ([]string, error) {
+	begin := time.Now()
+	result, err := SMembers(c.cache, key)
+	c.observe("SMembers", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) Incr(key string, increment int64) (int64, error) {
+	begin := time.Now()
+	result, err := c.cache.Incr(key, increment)
+	c.observe("Incr", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	begin := time.Now()
+	err := SetBytes(c.cache, key, value, ttl)
+	c.observe("SetBytes", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) GetBytes(key string) ([]byte, error) {
+	begin := time.Now()
+	result, err := GetBytes(c.cache, key)
+	c.observe("GetBytes", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	begin := time.Now()
+	result, err := AppendBytes(c.cache, dst, key)
+	c.observe("AppendBytes", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	begin := time.Now()
+	result, err := GetEx(c.cache, key, ttl)
+	c.observe("GetEx", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	begin := time.Now()
+	result, err := Expire(c.cache, key, ttl, cond)
+	c.observe("Expire", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	begin := time.Now()
+	result, err := ExpireAt(c.cache, key, deadline, cond)
+	c.observe("ExpireAt", begin, err)
+	return result, err
+}
+
+func (c *instrumentedExtCache) Persist(key string) error {
+	begin := time.Now()
+	err := Persist(c.cache, key)
+	c.observe("Persist", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) SetKeepTTL(key, value string) error {
+	begin := time.Now()
+	err := SetKeepTTL(c.cache, key, value)
+	c.observe("SetKeepTTL", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) Scan(prefix string, fn func(key string) bool) error {
+	begin := time.Now()
+	err := Scan(c.cache, prefix, fn)
+	c.observe("Scan", begin, err)
+	return err
+}
+
+func (c *instrumentedExtCache) Capabilities() Capability {
+	return Capabilities(c.cache)
+}
+
+func (c *instrumentedExtCache) SubCache(prefix string) Cache {
+	return c.SubExtendedCache(prefix)
+}
+
+func (c *instrumentedExtCache) SubExtendedCache(prefix string) ExtendedCache {
+	labels := c.labels
+	labels.Prefix += prefix
+	return NewInstrumentedExtendedCache(c.cache.SubExtendedCache(prefix), c.collector, labels)
+}
+
+func (c *instrumentedExtCache) Close() error {
+	begin := time.Now()
+	err := c.cache.Close()
+	c.observe("Close", begin, err)
+	return err
+}
@@ -0,0 +1,40 @@
+package razcache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestInstrumentedExtendedCache(t *testing.T) {
+	collector := NewStatsCollector()
+	cache := NewInstrumentedExtendedCache(inmem.NewInMemExtendedCache(), collector, MetricsLabels{Backend: "inmem"})
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+	testutil.TestIncr(t, cache)
+
+	subcache := cache.SubExtendedCache("prefix:")
+	assert.NoError(t, subcache.SAdd("set", "a"))
+
+	ops := make(map[string]OpStats)
+	for _, stats := range collector.Snapshot() {
+		ops[stats.Prefix+stats.Op] = stats
+	}
+	assert.Equal(t, uint64(3), ops["LPush"].Calls)
+	assert.Equal(t, uint64(3), ops["LPush"].Hits+ops["LPush"].Errors)
+	assert.Equal(t, uint64(1), ops["LPush"].Errors)
+	assert.Equal(t, uint64(1), ops["prefix:SAdd"].Calls)
+}
+
+func TestInstrumentedExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		return NewInstrumentedExtendedCache(inmem.NewInMemExtendedCache(), NewStatsCollector(), MetricsLabels{Backend: "inmem"})
+	})
+}
@@ -0,0 +1,35 @@
+package razcache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// LoggingMiddleware logs every operation with its keys, duration and error.
+// Failed operations are logged at error level, except for ErrNotFound.
+// Values are never logged, only the number of arguments.
+func LoggingMiddleware(logger *slog.Logger, level slog.Level) Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation) {
+			next(op)
+			opLevel := level
+			if op.Err != nil && !errors.Is(op.Err, ErrNotFound) {
+				opLevel = slog.LevelError
+			}
+			if !logger.Enabled(context.Background(), opLevel) {
+				return
+			}
+			attrs := []slog.Attr{
+				slog.String("op", op.Name),
+				slog.Any("keys", op.Keys),
+				slog.Int("args", len(op.Args)),
+				slog.Duration("duration", op.Duration),
+			}
+			if op.Err != nil {
+				attrs = append(attrs, slog.String("error", op.Err.Error()))
+			}
+			logger.LogAttrs(context.Background(), opLevel, "razcache", attrs...)
+		}
+	}
+}
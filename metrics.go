@@ -0,0 +1,166 @@
+package razcache
+
+import (
+	"errors"
+	"expvar"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Outcome int
+
+const (
+	OutcomeHit Outcome = iota
+	OutcomeMiss
+	OutcomeError
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeHit:
+		return "hit"
+	case OutcomeMiss:
+		return "miss"
+	default:
+		return "error"
+	}
+}
+
+func outcomeOf(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeHit
+	case errors.Is(err, ErrNotFound):
+		return OutcomeMiss
+	default:
+		return OutcomeError
+	}
+}
+
+type MetricsLabels struct {
+	Backend string
+	Prefix  string
+}
+
+// MetricsCollector receives the outcome and latency of every operation of an
+// instrumented cache. Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	Observe(op string, labels MetricsLabels, outcome Outcome, latency time.Duration)
+}
+
+// LatencyBuckets are the upper bounds of the latency histogram buckets.
+// The last bucket of a histogram counts everything above the last bound.
+var LatencyBuckets = []time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// OpStats is a snapshot of the statistics of one operation.
+// Hits counts every successful call, Misses the ErrNotFound results.
+type OpStats struct {
+	Backend string        `json:"backend"`
+	Prefix  string        `json:"prefix"`
+	Op      string        `json:"op"`
+	Calls   uint64        `json:"calls"`
+	Hits    uint64        `json:"hits"`
+	Misses  uint64        `json:"misses"`
+	Errors  uint64        `json:"errors"`
+	Latency time.Duration `json:"latency_ns"`
+	Buckets []uint64      `json:"buckets"`
+}
+
+type opStatsKey struct {
+	labels MetricsLabels
+	op     string
+}
+
+type opCounters struct {
+	outcomes [3]atomic.Uint64
+	latency  atomic.Int64
+	buckets  []atomic.Uint64
+}
+
+// StatsCollector is a MetricsCollector that keeps counters and latency
+// histograms in memory
+type StatsCollector struct {
+	stats sync.Map // opStatsKey -> *opCounters
+}
+
+func NewStatsCollector() *StatsCollector {
+	return new(StatsCollector)
+}
+
+func (s *StatsCollector) Observe(op string, labels MetricsLabels, outcome Outcome, latency time.Duration) {
+	key := opStatsKey{labels: labels, op: op}
+	value, ok := s.stats.Load(key)
+	if !ok {
+		value, _ = s.stats.LoadOrStore(key, &opCounters{
+			buckets: make([]atomic.Uint64, len(LatencyBuckets)+1),
+		})
+	}
+	counters := value.(*opCounters)
+	counters.outcomes[outcome].Add(1)
+	counters.latency.Add(int64(latency))
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return latency <= LatencyBuckets[i]
+	})
+	counters.buckets[bucket].Add(1)
+}
+
+// Snapshot returns the current statistics sorted by backend, prefix and operation
+func (s *StatsCollector) Snapshot() []OpStats {
+	var snapshot []OpStats
+	s.stats.Range(func(k, v any) bool {
+		key := k.(opStatsKey)
+		counters := v.(*opCounters)
+		stats := OpStats{
+			Backend: key.labels.Backend,
+			Prefix:  key.labels.Prefix,
+			Op:      key.op,
+			Hits:    counters.outcomes[OutcomeHit].Load(),
+			Misses:  counters.outcomes[OutcomeMiss].Load(),
+			Errors:  counters.outcomes[OutcomeError].Load(),
+			Latency: time.Duration(counters.latency.Load()),
+			Buckets: make([]uint64, len(counters.buckets)),
+		}
+		stats.Calls = stats.Hits + stats.Misses + stats.Errors
+		for i := range counters.buckets {
+			stats.Buckets[i] = counters.buckets[i].Load()
+		}
+		snapshot = append(snapshot, stats)
+		return true
+	})
+	sort.Slice(snapshot, func(i, j int) bool {
+		a, b := snapshot[i], snapshot[j]
+		if a.Backend != b.Backend {
+			return a.Backend < b.Backend
+		}
+		if a.Prefix != b.Prefix {
+			return a.Prefix < b.Prefix
+		}
+		return a.Op < b.Op
+	})
+	return snapshot
+}
+
+// Publish exports the snapshot as an expvar variable.
+// Like expvar.Publish, it panics if the name is already in use.
+func (s *StatsCollector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return s.Snapshot()
+	}))
+}
@@ -0,0 +1,245 @@
+package razcache
+
+import (
+	"time"
+)
+
+// Operation describes a single cache call passing through a middleware chain.
+// Result, Err and Duration are filled once the call returns, and middlewares
+// are allowed to change Result and Err.
+type Operation struct {
+	Name     string
+	Keys     []string
+	Args     []any
+	Result   any
+	Err      error
+	Duration time.Duration
+
+	invoke func() (any, error)
+}
+
+type Handler func(op *Operation)
+
+type Middleware func(next Handler) Handler
+
+// Chain combines middlewares into one, where the first one is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+func invokeOperation(op *Operation) {
+	begin := time.Now()
+	op.Result, op.Err = op.invoke()
+	op.Duration = time.Since(begin)
+}
+
+type middlewareCache struct {
+	cache   Cache
+	handler Handler
+}
+
+// NewMiddlewareCache passes every operation through the middlewares.
+// Sub caches are created on top of the returned cache, so they keep the chain.
+func NewMiddlewareCache(cache Cache, middlewares ...Middleware) Cache {
+	return &middlewareCache{
+		cache:   cache,
+		handler: Chain(middlewares...)(invokeOperation),
+	}
+}
+
+func (c *middlewareCache) handle(op *Operation, invoke func() (any, error)) {
+	op.invoke = invoke
+	c.handler(op)
+}
+
+func (c *middlewareCache) Set(key, value string, ttl time.Duration) error {
+	op := &Operation{
+		Name: "Set",
+		Keys: []string{key},
+		Args: []any{value, ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Set(key, value, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) Get(key string) (string, error) {
+	op := &Operation{
+		Name: "Get",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.Get(key)
+	})
+	result, _ := op.Result.(string)
+	return result, op.Err
+}
+
+func (c *middlewareCache) Del(key string) error {
+	op := &Operation{
+		Name: "Del",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Del(key)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) GetTTL(key string) (time.Duration, error) {
+	op := &Operation{
+		Name: "GetTTL",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.GetTTL(key)
+	})
+	result, _ := op.Result.(time.Duration)
+	return result, op.Err
+}
+
+func (c *middlewareCache) SetTTL(key string, ttl time.Duration) error {
+	op := &Operation{
+		Name: "SetTTL",
+		Keys: []string{key},
+		Args: []any{ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.SetTTL(key, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	op := &Operation{
+		Name: "SetBytes",
+		Keys: []string{key},
+		Args: []any{value, ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, SetBytes(c.cache, key, value, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) GetBytes(key string) ([]byte, error) {
+	op := &Operation{
+		Name: "GetBytes",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return GetBytes(c.cache, key)
+	})
+	result, _ := op.Result.([]byte)
+	return result, op.Err
+}
+
+func (c *middlewareCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	op := &Operation{
+		Name: "AppendBytes",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return AppendBytes(c.cache, dst, key)
+	})
+	if result, ok := op.Result.([]byte); ok {
+		return result, op.Err
+	}
+	return dst, op.Err
+}
+
+func (c *middlewareCache) GetEx(key string, ttl time.Duration) (string, error) {
+	op := &Operation{
+		Name: "GetEx",
+		Keys: []string{key},
+		Args: []any{ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return GetEx(c.cache, key, ttl)
+	})
+	result, _ := op.Result.(string)
+	return result, op.Err
+}
+
+func (c *middlewareCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	op := &Operation{
+		Name: "Expire",
+		Keys: []string{key},
+		Args: []any{ttl, cond},
+	}
+	c.handle(op, func() (any, error) {
+		return Expire(c.cache, key, ttl, cond)
+	})
+	result, _ := op.Result.(bool)
+	return result, op.Err
+}
+
+func (c *middlewareCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	op := &Operation{
+		Name: "ExpireAt",
+		Keys: []string{key},
+		Args: []any{deadline, cond},
+	}
+	c.handle(op, func() (any, error) {
+		return ExpireAt(c.cache, key, deadline, cond)
+	})
+	result, _ := op.Result.(bool)
+	return result, op.Err
+}
+
+func (c *middlewareCache) Persist(key string) error {
+	op := &Operation{
+		Name: "Persist",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, Persist(c.cache, key)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) SetKeepTTL(key, value string) error {
+	op := &Operation{
+		Name: "SetKeepTTL",
+		Keys: []string{key},
+		Args: []any{value},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, SetKeepTTL(c.cache, key, value)
+	})
+	return op.Err
+}
+
+// Scan passes the prefix as the key of the operation
+func (c *middlewareCache) Scan(prefix string, fn func(key string) bool) error {
+	op := &Operation{
+		Name: "Scan",
+		Keys: []string{prefix},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, Scan(c.cache, prefix, fn)
+	})
+	return op.Err
+}
+
+func (c *middlewareCache) Capabilities() Capability {
+	return Capabilities(c.cache) & capOptional
+}
+
+func (c *middlewareCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *middlewareCache) Close() error {
+	op := &Operation{Name: "Close"}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Close()
+	})
+	return op.Err
+}
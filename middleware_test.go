@@ -0,0 +1,135 @@
+package razcache_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation) {
+			*calls = append(*calls, name+">"+op.Name+strings.Join(op.Keys, ","))
+			next(op)
+			*calls = append(*calls, name+"<"+op.Name)
+		}
+	}
+}
+
+func TestMiddlewareCache(t *testing.T) {
+	var calls []string
+	cache := NewMiddlewareCache(inmem.NewInMemCache(),
+		recordingMiddleware("a", &calls),
+		recordingMiddleware("b", &calls))
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+
+	// first middleware should be the outermost
+	calls = nil
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assert.Equal(t, []string{"a>Setkey", "b>Setkey", "b<Set", "a<Set"}, calls)
+
+	// sub caches should keep the chain
+	calls = nil
+	_, err := cache.SubCache("prefix:").Get("key")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, []string{"a>Getprefix:key", "b>Getprefix:key", "b<Get", "a<Get"}, calls)
+}
+
+func TestMiddlewareCacheOverride(t *testing.T) {
+	// middlewares should be able to change results
+	defaultValue := func(next Handler) Handler {
+		return func(op *Operation) {
+			next(op)
+			if op.Name == "Get" && op.Err == ErrNotFound {
+				op.Result, op.Err = "default", nil
+			}
+		}
+	}
+	cache := NewMiddlewareCache(inmem.NewInMemCache(), defaultValue)
+	defer cache.Close()
+
+	value, err := cache.Get("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", value)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	cache := NewMiddlewareCache(inmem.NewInMemCache(), LoggingMiddleware(logger, slog.LevelInfo))
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("key", "secret value", 0))
+	cache.Get("missing")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "op=Set keys=[key] args=2")
+	assert.NotContains(t, lines[0], "secret value")
+	assert.Contains(t, lines[1], "level=INFO")
+	assert.Contains(t, lines[1], `error="not found"`)
+
+	// wrapped misses aren't errors either
+	buf.Reset()
+	handler := LoggingMiddleware(logger, slog.LevelInfo)(func(op *Operation) {
+		op.Err = fmt.Errorf("inner: %w", ErrNotFound)
+	})
+	handler(&Operation{Name: "Get", Keys: []string{"missing"}})
+	assert.Contains(t, buf.String(), "level=INFO")
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *testSpan) RecordError(err error)              { s.err = err }
+func (s *testSpan) End()                               { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(name string) Span {
+	span := &testSpan{name: name, attrs: make(map[string]any)}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	tracer := new(testTracer)
+	cache := NewMiddlewareExtendedCache(inmem.NewInMemExtendedCache(), TracingMiddleware(tracer))
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assert.Equal(t, ErrWrongType, cache.LPush("key", "a"))
+
+	assert.Len(t, tracer.spans, 2)
+	assert.Equal(t, "razcache.Set", tracer.spans[0].name)
+	assert.Equal(t, []string{"key"}, tracer.spans[0].attrs["razcache.keys"])
+	assert.True(t, tracer.spans[0].ended)
+	assert.Nil(t, tracer.spans[0].err)
+	assert.Equal(t, "razcache.LPush", tracer.spans[1].name)
+	assert.Equal(t, ErrWrongType, tracer.spans[1].err)
+
+	// wrapped misses aren't recorded as errors
+	handler := TracingMiddleware(tracer)(func(op *Operation) {
+		op.Err = fmt.Errorf("inner: %w", ErrNotFound)
+	})
+	handler(&Operation{Name: "Get", Keys: []string{"missing"}})
+	assert.Len(t, tracer.spans, 3)
+	assert.Nil(t, tracer.spans[2].err)
+}
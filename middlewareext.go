@@ -0,0 +1,388 @@
+package razcache
+
+import (
+	"time"
+)
+
+type middlewareExtCache struct {
+	cache   ExtendedCache
+	handler Handler
+}
+
+func NewMiddlewareExtendedCache(cache ExtendedCache, middlewares ...Middleware) ExtendedCache {
+	return &middlewareExtCache{
+		cache:   cache,
+		handler: Chain(middlewares...)(invokeOperation),
+	}
+}
+
+func (c *middlewareExtCache) handle(op *Operation, invoke func() (any, error)) {
+	op.invoke = invoke
+	c.handler(op)
+}
+
+func (c *middlewareExtCache) Set(key, value string, ttl time.Duration) error {
+	op := &Operation{
+		Name: "Set",
+		Keys: []string{key},
+		Args: []any{value, ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Set(key, value, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) Get(key string) (string, error) {
+	op := &Operation{
+		Name: "Get",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.Get(key)
+	})
+	result, _ := op.Result.(string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) Del(key string) error {
+	op := &Operation{
+		Name: "Del",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Del(key)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) GetTTL(key string) (time.Duration, error) {
+	op := &Operation{
+		Name: "GetTTL",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.GetTTL(key)
+	})
+	result, _ := op.Result.(time.Duration)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SetTTL(key string, ttl time.Duration) error {
+	op := &Operation{
+		Name: "SetTTL",
+		Keys: []string{key},
+		Args: []any{ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.SetTTL(key, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) LPush(key string, values ...string) error {
+	op := &Operation{
+		Name: "LPush",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.LPush(key, values...)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) RPush(key string, values ...string) error {
+	op := &Operation{
+		Name: "RPush",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.RPush(key, values...)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) LPop(key string, count int) ([]string, error) {
+	op := &Operation{
+		Name: "LPop",
+		Keys: []string{key},
+		Args: []any{count},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.LPop(key, count)
+	})
+	result, _ := op.Result.([]string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) RPop(key string, count int) ([]string, error) {
+	op := &Operation{
+		Name: "RPop",
+		Keys: []string{key},
+		Args: []any{count},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.RPop(key, count)
+	})
+	result, _ := op.Result.([]string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) LLen(key string) (int, error) {
+	op := &Operation{
+		Name: "LLen",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.LLen(key)
+	})
+	result, _ := op.Result.(int)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) LRange(key string, start, stop int) ([]string, error) {
+	op := &Operation{
+		Name: "LRange",
+		Keys: []string{key},
+		Args: []any{start, stop},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.LRange(key, start, stop)
+	})
+	result, _ := op.Result.([]string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SAdd(key string, values ...string) error {
+	op := &Operation{
+		Name: "SAdd",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.SAdd(key, values...)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) SRem(key string, values ...string) error {
+	op := &Operation{
+		Name: "SRem",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.SRem(key, values...)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) SAddCount(key string, values ...string) (int, error) {
+	op := &Operation{
+		Name: "SAddCount",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return SAddCount(c.cache, key, values...)
+	})
+	result, _ := op.Result.(int)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SRemCount(key string, values ...string) (int, error) {
+	op := &Operation{
+		Name: "SRemCount",
+		Keys: []string{key},
+		Args: []any{values},
+	}
+	c.handle(op, func() (any, error) {
+		return SRemCount(c.cache, key, values...)
+	})
+	result, _ := op.Result.(int)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SHas(key, value string) (bool, error) {
+	op := &Operation{
+		Name: "SHas",
+		Keys: []string{key},
+		Args: []any{value},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.SHas(key, value)
+	})
+	result, _ := op.Result.(bool)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SLen(key string) (int, error) {
+	op := &Operation{
+		Name: "SLen",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.SLen(key)
+	})
+	result, _ := op.Result.(int)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SMembers(key string) ([]string, error) {
+	op := &Operation{
+		Name: "SMembers",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return SMembers(c.cache, key)
+	})
+	result, _ := op.Result.([]string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) Incr(key string, increment int64) (int64, error) {
+	op := &Operation{
+		Name: "Incr",
+		Keys: []string{key},
+		Args: []any{increment},
+	}
+	c.handle(op, func() (any, error) {
+		return c.cache.Incr(key, increment)
+	})
+	result, _ := op.Result.(int64)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	op := &Operation{
+		Name: "SetBytes",
+		Keys: []string{key},
+		Args: []any{value, ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, SetBytes(c.cache, key, value, ttl)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) GetBytes(key string) ([]byte, error) {
+	op := &Operation{
+		Name: "GetBytes",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return GetBytes(c.cache, key)
+	})
+	result, _ := op.Result.([]byte)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	op := &Operation{
+		Name: "AppendBytes",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return AppendBytes(c.cache, dst, key)
+	})
+	if result, ok := op.Result.([]byte); ok {
+		return result, op.Err
+	}
+	return dst, op.Err
+}
+
+func (c *middlewareExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	op := &Operation{
+		Name: "GetEx",
+		Keys: []string{key},
+		Args: []any{ttl},
+	}
+	c.handle(op, func() (any, error) {
+		return GetEx(c.cache, key, ttl)
+	})
+	result, _ := op.Result.(string)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	op := &Operation{
+		Name: "Expire",
+		Keys: []string{key},
+		Args: []any{ttl, cond},
+	}
+	c.handle(op, func() (any, error) {
+		return Expire(c.cache, key, ttl, cond)
+	})
+	result, _ := op.Result.(bool)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	op := &Operation{
+		Name: "ExpireAt",
+		Keys: []string{key},
+		Args: []any{deadline, cond},
+	}
+	c.handle(op, func() (any, error) {
+		return ExpireAt(c.cache, key, deadline, cond)
+	})
+	result, _ := op.Result.(bool)
+	return result, op.Err
+}
+
+func (c *middlewareExtCache) Persist(key string) error {
+	op := &Operation{
+		Name: "Persist",
+		Keys: []string{key},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, Persist(c.cache, key)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) SetKeepTTL(key, value string) error {
+	op := &Operation{
+		Name: "SetKeepTTL",
+		Keys: []string{key},
+		Args: []any{value},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, SetKeepTTL(c.cache, key, value)
+	})
+	return op.Err
+}
+
+// Scan passes the prefix as the key of the operation
+func (c *middlewareExtCache) Scan(prefix string, fn func(key string) bool) error {
+	op := &Operation{
+		Name: "Scan",
+		Keys: []string{prefix},
+	}
+	c.handle(op, func() (any, error) {
+		return nil, Scan(c.cache, prefix, fn)
+	})
+	return op.Err
+}
+
+func (c *middlewareExtCache) Capabilities() Capability {
+	return Capabilities(c.cache)
+}
+
+func (c *middlewareExtCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *middlewareExtCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *middlewareExtCache) Close() error {
+	op := &Operation{Name: "Close"}
+	c.handle(op, func() (any, error) {
+		return nil, c.cache.Close()
+	})
+	return op.Err
+}
@@ -0,0 +1,44 @@
+package razcache_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestMiddlewareExtendedCache(t *testing.T) {
+	var calls []string
+	cache := NewMiddlewareExtendedCache(inmem.NewInMemExtendedCache(), recordingMiddleware("a", &calls))
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+	testutil.TestIncr(t, cache)
+
+	calls = nil
+	_, err := cache.SubExtendedCache("prefix:").Incr("counter", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a>Incrprefix:counter", "a<Incr"}, calls)
+}
+
+func TestMiddlewareExtendedCacheConformance(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		return NewMiddlewareExtendedCache(inmem.NewInMemExtendedCache(), func(next Handler) Handler {
+			return func(op *Operation) {
+				mu.Lock()
+				calls = append(calls, op.Name)
+				mu.Unlock()
+				next(op)
+			}
+		})
+	})
+	assert.NotEmpty(t, calls)
+}
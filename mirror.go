@@ -0,0 +1,339 @@
+package razcache
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultMirrorQueueSize = 1024
+
+type MirrorOptions struct {
+	// Write to secondaries in a background goroutine
+	Async bool
+	// Size of the async write queue (writes block while it is full)
+	QueueSize int
+	// Read from secondaries if the primary fails with an unexpected error
+	ReadFallback bool
+	// Compare the results of reads with the secondaries. It's ignored with Async,
+	// as reads could be compared before pending writes reach the secondaries.
+	VerifyReads bool
+	// Called when a read from a secondary differs from the primary
+	OnMismatch func(op, key string)
+	// Called when a write to a secondary fails
+	OnError func(op, key string, err error)
+}
+
+type MirrorStats struct {
+	Mismatches      uint64
+	SecondaryErrors uint64
+	Fallbacks       uint64
+}
+
+type mirror[T Cache] struct {
+	primary         T
+	secondaries     []T
+	opts            MirrorOptions
+	queue           chan func()
+	queueMu         sync.RWMutex // guards sending to the queue against closing it
+	queueClosed     bool
+	wg              sync.WaitGroup
+	closeOnce       sync.Once
+	mismatches      atomic.Uint64
+	secondaryErrors atomic.Uint64
+	fallbacks       atomic.Uint64
+}
+
+func (m *mirror[T]) init(primary T, secondaries []T, opts MirrorOptions) {
+	m.primary = primary
+	m.secondaries = secondaries
+	m.opts = opts
+	if opts.Async {
+		if opts.QueueSize <= 0 {
+			opts.QueueSize = defaultMirrorQueueSize
+		}
+		m.queue = make(chan func(), opts.QueueSize)
+		m.wg.Add(1)
+		go m.worker()
+	}
+}
+
+func (m *mirror[T]) worker() {
+	defer m.wg.Done()
+	for write := range m.queue {
+		write()
+	}
+}
+
+// write applies the operation on the primary, and on success on the secondaries too
+func (m *mirror[T]) write(op, key string, apply func(T) error) error {
+	if err := apply(m.primary); err != nil {
+		return err
+	}
+	m.replicate(op, key, apply)
+	return nil
+}
+
+// replicate applies the operation on the secondaries
+func (m *mirror[T]) replicate(op, key string, apply func(T) error) {
+	mirrorWrite := func() {
+		for _, secondary := range m.secondaries {
+			if err := apply(secondary); err != nil {
+				m.secondaryErrors.Add(1)
+				if m.opts.OnError != nil {
+					m.opts.OnError(op, key, err)
+				}
+			}
+		}
+	}
+	if m.queue == nil {
+		mirrorWrite()
+		return
+	}
+	// writes racing with close are dropped, the primary is closed too by then
+	m.queueMu.RLock()
+	defer m.queueMu.RUnlock()
+	if !m.queueClosed {
+		m.queue <- mirrorWrite
+	}
+}
+
+func (m *mirror[T]) mismatch(op, key string) {
+	m.mismatches.Add(1)
+	if m.opts.OnMismatch != nil {
+		m.opts.OnMismatch(op, key)
+	}
+}
+
+func (m *mirror[T]) stats() MirrorStats {
+	return MirrorStats{
+		Mismatches:      m.mismatches.Load(),
+		SecondaryErrors: m.secondaryErrors.Load(),
+		Fallbacks:       m.fallbacks.Load(),
+	}
+}
+
+// close waits for pending async writes, then closes all caches
+func (m *mirror[T]) close() error {
+	var errs []error
+	m.closeOnce.Do(func() {
+		if m.queue != nil {
+			m.queueMu.Lock()
+			m.queueClosed = true
+			close(m.queue)
+			m.queueMu.Unlock()
+			m.wg.Wait()
+		}
+		errs = append(errs, m.primary.Close())
+		for _, secondary := range m.secondaries {
+			errs = append(errs, secondary.Close())
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// capabilities returns the ones all caches have, and the ones of the primary
+// for operations the secondaries don't need to support
+func (m *mirror[T]) capabilities() Capability {
+	caps := commonCapabilities(append([]T{m.primary}, m.secondaries...)...)
+	return caps | Capabilities(m.primary)&(CapScan|CapGetEx|CapSetCount)
+}
+
+func mirrorRead[T Cache, R any](m *mirror[T], op, key string, read func(T) (R, error), equal func(a, b R) bool) (R, error) {
+	result, err := read(m.primary)
+	if err != nil && !isExpectedError(err) {
+		if m.opts.ReadFallback {
+			for _, secondary := range m.secondaries {
+				if result, serr := read(secondary); serr == nil || isExpectedError(serr) {
+					m.fallbacks.Add(1)
+					return result, serr
+				}
+			}
+		}
+		return result, err
+	}
+	if m.opts.VerifyReads && m.queue == nil {
+		for _, secondary := range m.secondaries {
+			sresult, serr := read(secondary)
+			if !sameExpectedError(err, serr) || (err == nil && !equal(result, sresult)) {
+				m.mismatch(op, key)
+			}
+		}
+	}
+	return result, err
+}
+
+// expectedError returns ErrNotFound or ErrWrongType if err is one of them, otherwise nil
+func expectedError(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrWrongType} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+func isExpectedError(err error) bool {
+	return expectedError(err) != nil
+}
+
+// sameExpectedError reports if both errors are nil, or both are the same expected error
+func sameExpectedError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	kind := expectedError(a)
+	return kind != nil && kind == expectedError(b)
+}
+
+func equalValues[T comparable](a, b T) bool {
+	return a == b
+}
+
+// equalMembers compares the slices ignoring the order of elements
+func equalMembers[T cmp.Ordered](a, b []T) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// MirrorCache writes to a primary and any number of secondary caches,
+// while it reads from the primary only
+type MirrorCache struct {
+	m mirror[Cache]
+}
+
+func NewMirrorCache(primary Cache, secondaries []Cache, opts MirrorOptions) *MirrorCache {
+	c := new(MirrorCache)
+	c.m.init(primary, secondaries, opts)
+	return c
+}
+
+func (c *MirrorCache) Stats() MirrorStats {
+	return c.m.stats()
+}
+
+func (c *MirrorCache) Set(key, value string, ttl time.Duration) error {
+	return c.m.write("Set", key, func(cache Cache) error {
+		return cache.Set(key, value, ttl)
+	})
+}
+
+func (c *MirrorCache) Get(key string) (string, error) {
+	return mirrorRead(&c.m, "Get", key, func(cache Cache) (string, error) {
+		return cache.Get(key)
+	}, equalValues[string])
+}
+
+func (c *MirrorCache) Del(key string) error {
+	return c.m.write("Del", key, func(cache Cache) error {
+		return cache.Del(key)
+	})
+}
+
+// GetTTL is not verified against the secondaries, as their timing can differ
+func (c *MirrorCache) GetTTL(key string) (time.Duration, error) {
+	return mirrorRead(&c.m, "GetTTL", key, func(cache Cache) (time.Duration, error) {
+		return cache.GetTTL(key)
+	}, func(time.Duration, time.Duration) bool { return true })
+}
+
+func (c *MirrorCache) SetTTL(key string, ttl time.Duration) error {
+	return c.m.write("SetTTL", key, func(cache Cache) error {
+		return cache.SetTTL(key, ttl)
+	})
+}
+
+func (c *MirrorCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	value = slices.Clone(value)
+	return c.m.write("SetBytes", key, func(cache Cache) error {
+		return SetBytes(cache, key, value, ttl)
+	})
+}
+
+func (c *MirrorCache) GetBytes(key string) ([]byte, error) {
+	return mirrorRead(&c.m, "GetBytes", key, func(cache Cache) ([]byte, error) {
+		return GetBytes(cache, key)
+	}, bytes.Equal)
+}
+
+func (c *MirrorCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	value, err := c.GetBytes(key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
+
+// GetEx changes the TTL of the key on the secondaries too, but only reads the primary
+func (c *MirrorCache) GetEx(key string, ttl time.Duration) (string, error) {
+	result, err := GetEx(c.m.primary, key, ttl)
+	if err != nil {
+		return "", err
+	}
+	c.m.replicate("GetEx", key, func(cache Cache) error {
+		return cache.SetTTL(key, ttl)
+	})
+	return result, nil
+}
+
+// Expire applies the condition on the primary, and the secondaries follow its decision
+func (c *MirrorCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	ok, err := Expire(c.m.primary, key, ttl, cond)
+	if err != nil || !ok {
+		return ok, err
+	}
+	c.m.replicate("Expire", key, func(cache Cache) error {
+		_, err := Expire(cache, key, ttl, ExpireAlways)
+		return err
+	})
+	return true, nil
+}
+
+// ExpireAt applies the condition on the primary, and the secondaries follow its decision
+func (c *MirrorCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	ok, err := ExpireAt(c.m.primary, key, deadline, cond)
+	if err != nil || !ok {
+		return ok, err
+	}
+	c.m.replicate("ExpireAt", key, func(cache Cache) error {
+		_, err := ExpireAt(cache, key, deadline, ExpireAlways)
+		return err
+	})
+	return true, nil
+}
+
+func (c *MirrorCache) Persist(key string) error {
+	return c.m.write("Persist", key, func(cache Cache) error {
+		return Persist(cache, key)
+	})
+}
+
+func (c *MirrorCache) SetKeepTTL(key, value string) error {
+	return c.m.write("SetKeepTTL", key, func(cache Cache) error {
+		return SetKeepTTL(cache, key, value)
+	})
+}
+
+// Scan only scans the primary
+func (c *MirrorCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.m.primary, prefix, fn)
+}
+
+func (c *MirrorCache) Capabilities() Capability {
+	return c.m.capabilities() & capOptional
+}
+
+func (c *MirrorCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+// Close waits for pending async writes, then closes the primary and all secondaries
+func (c *MirrorCache) Close() error {
+	return c.m.close()
+}
@@ -0,0 +1,159 @@
+package razcache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+var errUnavailable = fmt.Errorf("connection refused: %w", ErrUnavailable)
+
+type unavailableCache struct{}
+
+func (unavailableCache) Set(key, value string, ttl time.Duration) error { return errUnavailable }
+func (unavailableCache) Get(key string) (string, error)                 { return "", errUnavailable }
+func (unavailableCache) Del(key string) error                           { return errUnavailable }
+func (unavailableCache) GetTTL(key string) (time.Duration, error)       { return 0, errUnavailable }
+func (unavailableCache) SetTTL(key string, ttl time.Duration) error     { return errUnavailable }
+func (c unavailableCache) SubCache(prefix string) Cache                 { return NewPrefixCache(c, prefix) }
+func (unavailableCache) Close() error                                   { return nil }
+
+func TestMirrorCache(t *testing.T) {
+	primary := inmem.NewInMemCache()
+	secondary := inmem.NewInMemCache()
+	cache := NewMirrorCache(primary, []Cache{secondary}, MirrorOptions{VerifyReads: true})
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+
+	// writes should reach the secondary
+	assert.NoError(t, cache.Set("a", "val_a", 0))
+	value, err := secondary.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	assert.Equal(t, uint64(0), cache.Stats().Mismatches)
+
+	// diverging secondary should be noticed on read
+	var mismatchedKey string
+	cache = NewMirrorCache(primary, []Cache{secondary}, MirrorOptions{
+		VerifyReads: true,
+		OnMismatch:  func(op, key string) { mismatchedKey = key },
+	})
+	assert.NoError(t, secondary.Set("a", "other", 0))
+	value, err = cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	assert.Equal(t, uint64(1), cache.Stats().Mismatches)
+	assert.Equal(t, "a", mismatchedKey)
+}
+
+func TestMirrorCacheAsync(t *testing.T) {
+	var calls []string
+	primary := inmem.NewInMemCache()
+	secondary := NewMiddlewareCache(inmem.NewInMemCache(), recordingMiddleware("s", &calls))
+	cache := NewMirrorCache(primary, []Cache{secondary}, MirrorOptions{Async: true})
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(t, cache.Set(key, "val_"+key, 0))
+	}
+	assert.NoError(t, cache.Del("b"))
+
+	// pending writes should be flushed before the secondary is closed
+	assert.NoError(t, cache.Close())
+	assert.NoError(t, cache.Close())
+	assert.Equal(t, []string{
+		"s>Seta", "s<Set",
+		"s>Setb", "s<Set",
+		"s>Setc", "s<Set",
+		"s>Delb", "s<Del",
+		"s>Close", "s<Close",
+	}, calls)
+	_, err := secondary.Get("a")
+	assert.ErrorIs(t, err, ErrCacheClosed)
+}
+
+func TestMirrorCacheAsyncVerifyReads(t *testing.T) {
+	// hold back writes to the secondary until the reads are done
+	release := make(chan struct{})
+	gate := func(next Handler) Handler {
+		return func(op *Operation) {
+			if op.Name == "Set" {
+				<-release
+			}
+			next(op)
+		}
+	}
+	primary := inmem.NewInMemCache()
+	secondary := NewMiddlewareCache(inmem.NewInMemCache(), gate)
+	cache := NewMirrorCache(primary, []Cache{secondary}, MirrorOptions{Async: true, VerifyReads: true})
+
+	assert.NoError(t, cache.Set("a", "val_a", 0))
+	value, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	close(release)
+	assert.NoError(t, cache.Close())
+	assert.Equal(t, uint64(0), cache.Stats().Mismatches)
+}
+
+func TestMirrorCacheWrappedErrors(t *testing.T) {
+	// backends might wrap ErrNotFound with the details of the operation
+	wrapNotFound := func(next Handler) Handler {
+		return func(op *Operation) {
+			next(op)
+			if op.Err == ErrNotFound {
+				op.Err = &Error{Op: op.Name, Kind: ErrNotFound, Err: op.Err}
+			}
+		}
+	}
+	primary := inmem.NewInMemCache()
+	secondary := NewMiddlewareCache(inmem.NewInMemCache(), wrapNotFound)
+	cache := NewMirrorCache(primary, []Cache{secondary}, MirrorOptions{VerifyReads: true})
+	defer cache.Close()
+
+	_, err := cache.Get("missing")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, uint64(0), cache.Stats().Mismatches)
+
+	// a secondary failing with an expected error is still treated as a fallback
+	cache = NewMirrorCache(unavailableCache{}, []Cache{secondary}, MirrorOptions{ReadFallback: true})
+	_, err = cache.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, uint64(1), cache.Stats().Fallbacks)
+}
+
+func TestMirrorCacheFallback(t *testing.T) {
+	secondary := inmem.NewInMemCache()
+	defer secondary.Close()
+	assert.NoError(t, secondary.Set("a", "val_a", 0))
+
+	cache := NewMirrorCache(unavailableCache{}, []Cache{secondary}, MirrorOptions{ReadFallback: true})
+	value, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	assert.Equal(t, uint64(1), cache.Stats().Fallbacks)
+
+	// failing primary should fail writes and keep the secondary untouched
+	assert.Equal(t, errUnavailable, cache.Set("b", "val_b", 0))
+	_, err = secondary.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMirrorCacheSecondaryErrors(t *testing.T) {
+	primary := inmem.NewInMemCache()
+	defer primary.Close()
+
+	var failedOp string
+	cache := NewMirrorCache(primary, []Cache{unavailableCache{}}, MirrorOptions{
+		OnError: func(op, key string, err error) { failedOp = op },
+	})
+	assert.NoError(t, cache.Set("a", "val_a", 0))
+	assert.Equal(t, uint64(1), cache.Stats().SecondaryErrors)
+	assert.Equal(t, "Set", failedOp)
+}
@@ -0,0 +1,267 @@
+package razcache
+
+import (
+	"bytes"
+	"slices"
+	"time"
+)
+
+// MirrorExtendedCache is the ExtendedCache counterpart of MirrorCache
+type MirrorExtendedCache struct {
+	m mirror[ExtendedCache]
+}
+
+func NewMirrorExtendedCache(primary ExtendedCache, secondaries []ExtendedCache, opts MirrorOptions) *MirrorExtendedCache {
+	c := new(MirrorExtendedCache)
+	c.m.init(primary, secondaries, opts)
+	return c
+}
+
+func (c *MirrorExtendedCache) Stats() MirrorStats {
+	return c.m.stats()
+}
+
+func (c *MirrorExtendedCache) Set(key, value string, ttl time.Duration) error {
+	return c.m.write("Set", key, func(cache ExtendedCache) error {
+		return cache.Set(key, value, ttl)
+	})
+}
+
+func (c *MirrorExtendedCache) Get(key string) (string, error) {
+	return mirrorRead(&c.m, "Get", key, func(cache ExtendedCache) (string, error) {
+		return cache.Get(key)
+	}, equalValues[string])
+}
+
+func (c *MirrorExtendedCache) Del(key string) error {
+	return c.m.write("Del", key, func(cache ExtendedCache) error {
+		return cache.Del(key)
+	})
+}
+
+func (c *MirrorExtendedCache) GetTTL(key string) (time.Duration, error) {
+	return mirrorRead(&c.m, "GetTTL", key, func(cache ExtendedCache) (time.Duration, error) {
+		return cache.GetTTL(key)
+	}, func(time.Duration, time.Duration) bool { return true })
+}
+
+func (c *MirrorExtendedCache) SetTTL(key string, ttl time.Duration) error {
+	return c.m.write("SetTTL", key, func(cache ExtendedCache) error {
+		return cache.SetTTL(key, ttl)
+	})
+}
+
+func (c *MirrorExtendedCache) LPush(key string, values ...string) error {
+	values = slices.Clone(values)
+	return c.m.write("LPush", key, func(cache ExtendedCache) error {
+		return cache.LPush(key, values...)
+	})
+}
+
+func (c *MirrorExtendedCache) RPush(key string, values ...string) error {
+	values = slices.Clone(values)
+	return c.m.write("RPush", key, func(cache ExtendedCache) error {
+		return cache.RPush(key, values...)
+	})
+}
+
+// LPop pops the same number of elements from the secondaries
+func (c *MirrorExtendedCache) LPop(key string, count int) ([]string, error) {
+	result, err := c.m.primary.LPop(key, count)
+	if err != nil {
+		return nil, err
+	}
+	c.m.replicate("LPop", key, func(cache ExtendedCache) error {
+		_, err := cache.LPop(key, count)
+		return err
+	})
+	return result, nil
+}
+
+// RPop pops the same number of elements from the secondaries
+func (c *MirrorExtendedCache) RPop(key string, count int) ([]string, error) {
+	result, err := c.m.primary.RPop(key, count)
+	if err != nil {
+		return nil, err
+	}
+	c.m.replicate("RPop", key, func(cache ExtendedCache) error {
+		_, err := cache.RPop(key, count)
+		return err
+	})
+	return result, nil
+}
+
+func (c *MirrorExtendedCache) LLen(key string) (int, error) {
+	return mirrorRead(&c.m, "LLen", key, func(cache ExtendedCache) (int, error) {
+		return cache.LLen(key)
+	}, equalValues[int])
+}
+
+func (c *MirrorExtendedCache) LRange(key string, start, stop int) ([]string, error) {
+	return mirrorRead(&c.m, "LRange", key, func(cache ExtendedCache) ([]string, error) {
+		return cache.LRange(key, start, stop)
+	}, slices.Equal[[]string])
+}
+
+func (c *MirrorExtendedCache) SAdd(key string, values ...string) error {
+	values = slices.Clone(values)
+	return c.m.write("SAdd", key, func(cache ExtendedCache) error {
+		return cache.SAdd(key, values...)
+	})
+}
+
+func (c *MirrorExtendedCache) SRem(key string, values ...string) error {
+	values = slices.Clone(values)
+	return c.m.write("SRem", key, func(cache ExtendedCache) error {
+		return cache.SRem(key, values...)
+	})
+}
+
+// SAddCount returns the count of the primary, while secondaries are updated with SAdd
+func (c *MirrorExtendedCache) SAddCount(key string, values ...string) (int, error) {
+	values = slices.Clone(values)
+	added, err := SAddCount(c.m.primary, key, values...)
+	if err != nil {
+		return 0, err
+	}
+	c.m.replicate("SAdd", key, func(cache ExtendedCache) error {
+		return cache.SAdd(key, values...)
+	})
+	return added, nil
+}
+
+// SRemCount returns the count of the primary, while secondaries are updated with SRem
+func (c *MirrorExtendedCache) SRemCount(key string, values ...string) (int, error) {
+	values = slices.Clone(values)
+	removed, err := SRemCount(c.m.primary, key, values...)
+	if err != nil {
+		return 0, err
+	}
+	c.m.replicate("SRem", key, func(cache ExtendedCache) error {
+		return cache.SRem(key, values...)
+	})
+	return removed, nil
+}
+
+func (c *MirrorExtendedCache) SHas(key, value string) (bool, error) {
+	return mirrorRead(&c.m, "SHas", key, func(cache ExtendedCache) (bool, error) {
+		return cache.SHas(key, value)
+	}, equalValues[bool])
+}
+
+func (c *MirrorExtendedCache) SLen(key string) (int, error) {
+	return mirrorRead(&c.m, "SLen", key, func(cache ExtendedCache) (int, error) {
+		return cache.SLen(key)
+	}, equalValues[int])
+}
+
+func (c *MirrorExtendedCache) SMembers(key string) ([]string, error) {
+	return mirrorRead(&c.m, "SMembers", key, func(cache ExtendedCache) ([]string, error) {
+		return SMembers(cache, key)
+	}, equalMembers[string])
+}
+
+// Incr increments the counters of the secondaries by the same amount
+func (c *MirrorExtendedCache) Incr(key string, increment int64) (int64, error) {
+	result, err := c.m.primary.Incr(key, increment)
+	if err != nil {
+		return 0, err
+	}
+	c.m.replicate("Incr", key, func(cache ExtendedCache) error {
+		_, err := cache.Incr(key, increment)
+		return err
+	})
+	return result, nil
+}
+
+func (c *MirrorExtendedCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	value = slices.Clone(value)
+	return c.m.write("SetBytes", key, func(cache ExtendedCache) error {
+		return SetBytes(cache, key, value, ttl)
+	})
+}
+
+func (c *MirrorExtendedCache) GetBytes(key string) ([]byte, error) {
+	return mirrorRead(&c.m, "GetBytes", key, func(cache ExtendedCache) ([]byte, error) {
+		return GetBytes(cache, key)
+	}, bytes.Equal)
+}
+
+func (c *MirrorExtendedCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	value, err := c.GetBytes(key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
+
+// GetEx changes the TTL of the key on the secondaries too, but only reads the primary
+func (c *MirrorExtendedCache) GetEx(key string, ttl time.Duration) (string, error) {
+	result, err := GetEx(c.m.primary, key, ttl)
+	if err != nil {
+		return "", err
+	}
+	c.m.replicate("GetEx", key, func(cache ExtendedCache) error {
+		return cache.SetTTL(key, ttl)
+	})
+	return result, nil
+}
+
+// Expire applies the condition on the primary, and the secondaries follow its decision
+func (c *MirrorExtendedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	ok, err := Expire(c.m.primary, key, ttl, cond)
+	if err != nil || !ok {
+		return ok, err
+	}
+	c.m.replicate("Expire", key, func(cache ExtendedCache) error {
+		_, err := Expire(cache, key, ttl, ExpireAlways)
+		return err
+	})
+	return true, nil
+}
+
+// ExpireAt applies the condition on the primary, and the secondaries follow its decision
+func (c *MirrorExtendedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	ok, err := ExpireAt(c.m.primary, key, deadline, cond)
+	if err != nil || !ok {
+		return ok, err
+	}
+	c.m.replicate("ExpireAt", key, func(cache ExtendedCache) error {
+		_, err := ExpireAt(cache, key, deadline, ExpireAlways)
+		return err
+	})
+	return true, nil
+}
+
+func (c *MirrorExtendedCache) Persist(key string) error {
+	return c.m.write("Persist", key, func(cache ExtendedCache) error {
+		return Persist(cache, key)
+	})
+}
+
+func (c *MirrorExtendedCache) SetKeepTTL(key, value string) error {
+	return c.m.write("SetKeepTTL", key, func(cache ExtendedCache) error {
+		return SetKeepTTL(cache, key, value)
+	})
+}
+
+// Scan only scans the primary
+func (c *MirrorExtendedCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.m.primary, prefix, fn)
+}
+
+func (c *MirrorExtendedCache) Capabilities() Capability {
+	return c.m.capabilities()
+}
+
+func (c *MirrorExtendedCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *MirrorExtendedCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *MirrorExtendedCache) Close() error {
+	return c.m.close()
+}
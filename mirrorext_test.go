@@ -0,0 +1,43 @@
+package razcache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestMirrorExtendedCache(t *testing.T) {
+	primary := inmem.NewInMemExtendedCache()
+	secondary := inmem.NewInMemExtendedCache()
+	cache := NewMirrorExtendedCache(primary, []ExtendedCache{secondary}, MirrorOptions{VerifyReads: true})
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+	testutil.TestIncr(t, cache)
+
+	// secondary should be identical to the primary
+	assert.Equal(t, uint64(0), cache.Stats().Mismatches)
+	assert.NoError(t, cache.RPush("list", "a", "b", "c"))
+	_, err := cache.LPop("list", 1)
+	assert.NoError(t, err)
+	result, err := secondary.LRange("list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, result)
+	value, err := secondary.Incr("int", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+}
+
+func TestMirrorExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		primary := inmem.NewInMemExtendedCache()
+		secondary := inmem.NewInMemExtendedCache()
+		return NewMirrorExtendedCache(primary, []ExtendedCache{secondary}, MirrorOptions{VerifyReads: true})
+	})
+}
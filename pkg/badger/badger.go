@@ -1,6 +1,8 @@
 package badger
 
 import (
+	"errors"
+	"strings"
 	"time"
 	"unsafe"
 
@@ -27,7 +29,7 @@ func NewBadgerCacheFromDB(db *badger.DB) razcache.Cache {
 }
 
 func (c *badgerCache) Get(key string) (val string, err error) {
-	err = translateBadgerError((*badger.DB)(c).View(func(txn *badger.Txn) error {
+	err = translateBadgerError("Get", key, (*badger.DB)(c).View(func(txn *badger.Txn) error {
 		item, err := txn.Get(yoloBytes(key))
 		if err != nil {
 			return err
@@ -40,31 +42,66 @@ func (c *badgerCache) Get(key string) (val string, err error) {
 	return
 }
 
+func (c *badgerCache) GetBytes(key string) ([]byte, error) {
+	return c.AppendBytes(nil, key)
+}
+
+func (c *badgerCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	val := dst
+	err := translateBadgerError("AppendBytes", key, (*badger.DB)(c).View(func(txn *badger.Txn) error {
+		item, err := txn.Get(yoloBytes(key))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(raw []byte) error {
+			val = append(dst, raw...)
+			return nil
+		})
+	}))
+	if err != nil {
+		return dst, err
+	}
+	return val, nil
+}
+
 func (c *badgerCache) Set(key string, value string, ttl time.Duration) error {
-	e := badger.NewEntry(yoloBytes(key), yoloBytes(value))
+	return c.SetBytes(key, yoloBytes(value), ttl)
+}
+
+// SetBytes doesn't copy the value, as badger is done with it once the transaction is committed
+func (c *badgerCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		return c.Del(key)
+	}
+	e := badger.NewEntry(yoloBytes(key), value)
 	if ttl > 0 {
-		e = e.WithTTL(ttl)
+		e.ExpiresAt = expiresAt(time.Now().Add(ttl))
 	}
-	return translateBadgerError((*badger.DB)(c).Update(func(txn *badger.Txn) error {
+	return translateBadgerError("SetBytes", key, (*badger.DB)(c).Update(func(txn *badger.Txn) error {
 		return txn.SetEntry(e)
 	}))
 }
 
 func (c *badgerCache) Del(key string) error {
-	return translateBadgerError((*badger.DB)(c).Update(func(txn *badger.Txn) error {
+	return translateBadgerError("Del", key, (*badger.DB)(c).Update(func(txn *badger.Txn) error {
 		return txn.Delete(yoloBytes(key))
 	}))
 }
 
 func (c *badgerCache) GetTTL(key string) (ttl time.Duration, err error) {
-	err = translateBadgerError((*badger.DB)(c).View(func(txn *badger.Txn) error {
+	err = translateBadgerError("GetTTL", key, (*badger.DB)(c).View(func(txn *badger.Txn) error {
 		item, err := txn.Get(yoloBytes(key))
 		if err != nil {
 			return err
 		}
 		exp := item.ExpiresAt()
-		if exp != 0 {
-			ttl = time.Until(time.Unix(int64(exp), 0))
+		if exp == 0 {
+			ttl = razcache.NoExpiry
+			return nil
+		}
+		// badger expires keys at the start of the second
+		if ttl = time.Until(time.Unix(int64(exp), 0)); ttl <= 0 {
+			return badger.ErrKeyNotFound
 		}
 		return nil
 	}))
@@ -72,23 +109,104 @@ func (c *badgerCache) GetTTL(key string) (ttl time.Duration, err error) {
 }
 
 func (c *badgerCache) SetTTL(key string, ttl time.Duration) error {
-	return translateBadgerError((*badger.DB)(c).Update(func(txn *badger.Txn) error {
+	_, err := c.GetEx(key, ttl)
+	return err
+}
+
+// GetEx rewrites the entry with the new TTL in the same transaction as reading it
+func (c *badgerCache) GetEx(key string, ttl time.Duration) (val string, err error) {
+	err = translateBadgerError("GetEx", key, (*badger.DB)(c).Update(func(txn *badger.Txn) error {
 		item, err := txn.Get(yoloBytes(key))
 		if err != nil {
 			return err
 		}
+		raw, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		val = string(raw)
+		if ttl < 0 {
+			return txn.Delete(yoloBytes(key))
+		}
+		e := badger.NewEntry(yoloBytes(key), raw)
+		if ttl > 0 {
+			e.ExpiresAt = expiresAt(time.Now().Add(ttl))
+		}
+		return txn.SetEntry(e)
+	}))
+	if err != nil {
+		val = ""
+	}
+	return
+}
+
+func (c *badgerCache) Expire(key string, ttl time.Duration, cond razcache.ExpireCondition) (bool, error) {
+	return c.ExpireAt(key, time.Now().Add(ttl), cond)
+}
+
+// ExpireAt rewrites the entry with the new deadline, which badger stores in seconds rounded up
+func (c *badgerCache) ExpireAt(key string, deadline time.Time, cond razcache.ExpireCondition) (ok bool, err error) {
+	err = translateBadgerError("ExpireAt", key, (*badger.DB)(c).Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(yoloBytes(key))
+		if err != nil {
+			return err
+		}
+		var current time.Time
+		if exp := item.ExpiresAt(); exp != 0 {
+			current = time.Unix(int64(exp), 0)
+		}
+		if ok = cond.Allows(current, time.Unix(int64(expiresAt(deadline)), 0)); !ok {
+			return nil
+		}
+		if !deadline.After(time.Now()) {
+			return txn.Delete(yoloBytes(key))
+		}
 		val, err := item.ValueCopy(nil)
 		if err != nil {
 			return err
 		}
 		e := badger.NewEntry(yoloBytes(key), val)
-		if ttl > 0 {
-			e = e.WithTTL(ttl)
+		e.ExpiresAt = expiresAt(deadline)
+		return txn.SetEntry(e)
+	}))
+	return
+}
+
+func (c *badgerCache) Persist(key string) error {
+	return c.SetTTL(key, 0)
+}
+
+func (c *badgerCache) SetKeepTTL(key, value string) error {
+	return translateBadgerError("SetKeepTTL", key, (*badger.DB)(c).Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry(yoloBytes(key), yoloBytes(value))
+		item, err := txn.Get(yoloBytes(key))
+		switch err {
+		case nil:
+			e.ExpiresAt = item.ExpiresAt()
+		case badger.ErrKeyNotFound:
+		default:
+			return err
 		}
 		return txn.SetEntry(e)
 	}))
 }
 
+func (c *badgerCache) Scan(prefix string, fn func(key string) bool) error {
+	return translateBadgerError("Scan", prefix, (*badger.DB)(c).View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if !fn(string(it.Item().Key())) {
+				break
+			}
+		}
+		return nil
+	}))
+}
+
 func (c *badgerCache) SubCache(prefix string) razcache.Cache {
 	return razcache.NewPrefixCache(c, prefix)
 }
@@ -97,11 +215,44 @@ func (c *badgerCache) Close() error {
 	return (*badger.DB)(c).Close()
 }
 
-func translateBadgerError(err error) error {
-	if err == badger.ErrKeyNotFound {
-		err = razcache.ErrNotFound
+// translateBadgerError returns ErrNotFound as it is,
+// and wraps other errors in a razcache.Error
+func translateBadgerError(op, key string, err error) error {
+	switch err {
+	case nil:
+		return nil
+	case badger.ErrKeyNotFound:
+		return razcache.ErrNotFound
 	}
-	return err
+	return &razcache.Error{Op: op, Key: key, Backend: "badger", Kind: badgerErrorKind(err), Err: err}
+}
+
+// badgerErrorKind classifies errors of badger, or returns nil if it can't
+func badgerErrorKind(err error) error {
+	switch {
+	case errors.Is(err, badger.ErrDBClosed):
+		return razcache.ErrCacheClosed
+	case errors.Is(err, badger.ErrConflict):
+		return razcache.ErrConflict
+	case errors.Is(err, badger.ErrTxnTooBig),
+		strings.HasPrefix(err.Error(), "Value with size"),
+		strings.HasPrefix(err.Error(), "Key with size"):
+		return razcache.ErrValueTooLarge
+	case errors.Is(err, badger.ErrBlockedWrites):
+		return razcache.ErrUnavailable
+	default:
+		return nil
+	}
+}
+
+// expiresAt rounds the deadline up to the seconds badger stores, as truncating it
+// could expire keys with sub-second TTLs right away
+func expiresAt(deadline time.Time) uint64 {
+	sec := deadline.Unix()
+	if deadline.Nanosecond() > 0 {
+		sec++
+	}
+	return uint64(sec)
 }
 
 func yoloBytes(s string) []byte {
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/razzie/razcache"
 	. "github.com/razzie/razcache/pkg/badger"
 	"github.com/razzie/razcache/pkg/testutil"
 	"github.com/stretchr/testify/require"
@@ -24,3 +25,47 @@ func TestBadgerCacheTTL(t *testing.T) {
 
 	testutil.TestTTL(t, cache, time.Second)
 }
+
+func TestBadgerCacheScan(t *testing.T) {
+	cache, err := NewBadgerCache("")
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NoError(t, cache.Set("a:1", "1", 0))
+	require.NoError(t, cache.Set("a:2", "2", 0))
+	require.NoError(t, cache.Set("b:1", "1", 0))
+
+	keys, err := razcache.ScanKeys(cache, "a:")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a:1", "a:2"}, keys)
+}
+
+func TestBadgerCacheErrorContext(t *testing.T) {
+	cache, err := NewBadgerCache("")
+	require.NoError(t, err)
+	require.NoError(t, cache.Close())
+
+	_, err = cache.SubCache("sub:").Get("key")
+	require.ErrorIs(t, err, razcache.ErrCacheClosed)
+	var cacheErr *razcache.Error
+	require.ErrorAs(t, err, &cacheErr)
+	require.Equal(t, "badger", cacheErr.Backend)
+	require.Equal(t, "Get", cacheErr.Op)
+	require.Equal(t, "sub:key", cacheErr.Key)
+}
+
+func TestBadgerCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) razcache.Cache {
+		cache, err := NewBadgerCache("")
+		require.NoError(t, err)
+		return cache
+	}, testutil.WithTTLGranularity(time.Second))
+}
+
+func BenchmarkBadgerCache(b *testing.B) {
+	testutil.RunBenchmarks(b, func(b *testing.B) razcache.Cache {
+		cache, err := NewBadgerCache("")
+		require.NoError(b, err)
+		return cache
+	})
+}
@@ -0,0 +1,306 @@
+package httpcache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+// TTLHeader carries TTLs in milliseconds. A negative TTL in a response means no expiry.
+const TTLHeader = "X-Razcache-TTL"
+
+// MaxBodySize is the largest request body the handler accepts
+const MaxBodySize = 32 << 20
+
+// error codes of responses, which tell cache errors apart from other errors with the same status
+const (
+	codeNotFound  = "not_found"
+	codeWrongType = "wrong_type"
+)
+
+var (
+	errBadRequest = errors.New("bad request")
+	errNoRoute    = errors.New("no such endpoint")
+)
+
+type handler struct {
+	cache razcache.ExtendedCache
+}
+
+// NewHandler exposes the cache over HTTP with the following endpoints,
+// where {key} is a single path escaped segment:
+//
+//	GET    /v1/keys/{key}                    value in body
+//	PUT    /v1/keys/{key}                    value in body, optional TTL header
+//	DELETE /v1/keys/{key}
+//	GET    /v1/ttl/{key}                     TTL header in response
+//	PUT    /v1/ttl/{key}                     TTL header
+//	POST   /v1/lists/{key}/lpush|rpush       JSON array of values
+//	POST   /v1/lists/{key}/lpop|rpop?count=N JSON array of values in response
+//	GET    /v1/lists/{key}/len               JSON number in response
+//	GET    /v1/lists/{key}/range?start=&stop=
+//	POST   /v1/sets/{key}/add|rem            JSON array of values
+//	GET    /v1/sets/{key}/has?value=         JSON boolean in response
+//	GET    /v1/sets/{key}/len
+//	GET    /v1/sets/{key}/members            JSON array of members in response
+//	POST   /v1/counters/{key}/incr?by=N      JSON number in response
+//
+// Errors are returned as {"error":"...","code":"..."} with 404 and code "not_found" for razcache.ErrNotFound,
+// 409 and code "wrong_type" for razcache.ErrWrongType, 413 for razcache.ErrValueTooLarge and bodies
+// larger than MaxBodySize, 501 for razcache.ErrNotSupported (like members of caches that
+// don't implement razcache.SetMemberLister), 503 for razcache.ErrUnavailable and
+// razcache.ErrCacheClosed, and 504 for razcache.ErrTimeout. Unknown endpoints are 404 without a code.
+func NewHandler(cache razcache.ExtendedCache) http.Handler {
+	return &handler{cache: cache}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+	path, ok := strings.CutPrefix(r.URL.EscapedPath(), "/v1/")
+	if !ok {
+		writeError(w, errNoRoute)
+		return
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 || len(parts[1]) == 0 {
+		writeError(w, errNoRoute)
+		return
+	}
+	key, err := url.PathUnescape(parts[1])
+	if err != nil {
+		writeError(w, errBadRequest)
+		return
+	}
+	route := parts[0]
+	if len(parts) == 3 {
+		route += "/" + parts[2]
+	}
+	route = r.Method + " " + route
+
+	switch route {
+	case "GET keys":
+		h.get(w, key)
+	case "PUT keys":
+		h.set(w, r, key)
+	case "DELETE keys":
+		writeResult(w, nil, h.cache.Del(key))
+	case "GET ttl":
+		h.getTTL(w, key)
+	case "PUT ttl":
+		h.setTTL(w, r, key)
+	case "POST lists/lpush":
+		h.push(w, r, key, h.cache.LPush)
+	case "POST lists/rpush":
+		h.push(w, r, key, h.cache.RPush)
+	case "POST lists/lpop":
+		h.pop(w, r, key, h.cache.LPop)
+	case "POST lists/rpop":
+		h.pop(w, r, key, h.cache.RPop)
+	case "GET lists/len":
+		length, err := h.cache.LLen(key)
+		writeResult(w, length, err)
+	case "GET lists/range":
+		h.lrange(w, r, key)
+	case "POST sets/add":
+		h.push(w, r, key, h.cache.SAdd)
+	case "POST sets/rem":
+		h.push(w, r, key, h.cache.SRem)
+	case "GET sets/has":
+		found, err := h.cache.SHas(key, r.URL.Query().Get("value"))
+		writeResult(w, found, err)
+	case "GET sets/len":
+		length, err := h.cache.SLen(key)
+		writeResult(w, length, err)
+	case "GET sets/members":
+		members, err := razcache.SMembers(h.cache, key)
+		if members == nil {
+			members = []string{}
+		}
+		writeResult(w, members, err)
+	case "POST counters/incr":
+		h.incr(w, r, key)
+	default:
+		writeError(w, errNoRoute)
+	}
+}
+
+func (h *handler) get(w http.ResponseWriter, key string) {
+	value, err := razcache.GetBytes(h.cache, key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+func (h *handler) set(w http.ResponseWriter, r *http.Request, key string) {
+	ttl, err := parseTTL(r.Header.Get(TTLHeader))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	value, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, bodyError(err))
+		return
+	}
+	writeResult(w, nil, razcache.SetBytes(h.cache, key, value, ttl))
+}
+
+func (h *handler) getTTL(w http.ResponseWriter, key string) {
+	ttl, err := h.cache.GetTTL(key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ms := int64(-1) // no expiry
+	if ttl > 0 {
+		// round up, so keys about to expire don't report 0
+		ms = int64((ttl + time.Millisecond - 1) / time.Millisecond)
+	}
+	w.Header().Set(TTLHeader, strconv.FormatInt(ms, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setTTL(w http.ResponseWriter, r *http.Request, key string) {
+	ttl, err := parseTTL(r.Header.Get(TTLHeader))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, nil, h.cache.SetTTL(key, ttl))
+}
+
+func (h *handler) push(w http.ResponseWriter, r *http.Request, key string, push func(key string, values ...string) error) {
+	var values []string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		writeError(w, bodyError(err))
+		return
+	}
+	writeResult(w, nil, push(key, values...))
+}
+
+func (h *handler) pop(w http.ResponseWriter, r *http.Request, key string, pop func(key string, count int) ([]string, error)) {
+	count, err := queryInt(r, "count", 1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	values, err := pop(key, count)
+	writeResult(w, values, err)
+}
+
+func (h *handler) lrange(w http.ResponseWriter, r *http.Request, key string) {
+	start, err := queryInt(r, "start", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	stop, err := queryInt(r, "stop", -1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	values, err := h.cache.LRange(key, start, stop)
+	writeResult(w, values, err)
+}
+
+func (h *handler) incr(w http.ResponseWriter, r *http.Request, key string) {
+	increment, err := strconv.ParseInt(r.URL.Query().Get("by"), 10, 64)
+	if err != nil {
+		writeError(w, errBadRequest)
+		return
+	}
+	value, err := h.cache.Incr(key, increment)
+	writeResult(w, value, err)
+}
+
+// bodyError returns ErrValueTooLarge if the body exceeded MaxBodySize, otherwise errBadRequest
+func bodyError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return razcache.ErrValueTooLarge
+	}
+	return errBadRequest
+}
+
+func parseTTL(header string) (time.Duration, error) {
+	if len(header) == 0 {
+		return 0, nil
+	}
+	ms, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		return 0, errBadRequest
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if len(value) == 0 {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errBadRequest
+	}
+	return i, nil
+}
+
+// writeResult writes the result as JSON, or no content if it's nil
+func writeResult(w http.ResponseWriter, result any, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var code string
+	switch {
+	case errors.Is(err, razcache.ErrNotFound):
+		status = http.StatusNotFound
+		code = codeNotFound
+	case errors.Is(err, razcache.ErrWrongType):
+		status = http.StatusConflict
+		code = codeWrongType
+	case errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+	case errors.Is(err, errNoRoute):
+		status = http.StatusNotFound
+	default:
+		switch {
+		case errors.Is(err, razcache.ErrValueTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, razcache.ErrUnavailable), errors.Is(err, razcache.ErrCacheClosed):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, razcache.ErrTimeout):
+			status = http.StatusGatewayTimeout
+		case errors.Is(err, razcache.ErrNotSupported):
+			status = http.StatusNotImplemented
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error(), Code: code})
+}
@@ -0,0 +1,300 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+type httpCache struct {
+	baseURL    string
+	client     *http.Client
+	ownsClient bool
+	closed     atomic.Bool
+}
+
+// NewHTTPCache returns a cache that talks to a server created by NewHandler.
+// It has its own connection pool, which Close closes.
+func NewHTTPCache(baseURL string) razcache.ExtendedCache {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c := newHTTPCache(baseURL, &http.Client{Transport: transport})
+	c.ownsClient = true
+	return c
+}
+
+// NewHTTPCacheFromClient returns a cache that uses the client, which Close leaves open
+func NewHTTPCacheFromClient(baseURL string, client *http.Client) razcache.ExtendedCache {
+	return newHTTPCache(baseURL, client)
+}
+
+func newHTTPCache(baseURL string, client *http.Client) *httpCache {
+	return &httpCache{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/v1/",
+		client:  client,
+	}
+}
+
+func (c *httpCache) url(resource, key, action string, query url.Values) string {
+	u := c.baseURL + resource + "/" + url.PathEscape(key)
+	if len(action) > 0 {
+		u += "/" + action
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// do sends the request of the operation on the key and decodes the JSON response into result (if not nil)
+func (c *httpCache) do(op, key, method, url string, body io.Reader, header http.Header, result any) (http.Header, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.closed.Load() {
+		return nil, requestError(op, key, razcache.ErrCacheClosed, razcache.ErrCacheClosed)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, requestError(op, key, transportErrorKind(err), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, translateHTTPError(op, key, resp)
+	}
+	if result != nil {
+		switch raw := result.(type) {
+		case *string:
+			value, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, requestError(op, key, transportErrorKind(err), err)
+			}
+			*raw = string(value)
+			return resp.Header, nil
+		case *[]byte:
+			// appends to the slice, so AppendBytes can reuse its buffer
+			buf := bytes.NewBuffer(*raw)
+			_, err := buf.ReadFrom(resp.Body)
+			*raw = buf.Bytes()
+			if err != nil {
+				return nil, requestError(op, key, transportErrorKind(err), err)
+			}
+			return resp.Header, nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return nil, requestError(op, key, transportErrorKind(err), err)
+		}
+	}
+	return resp.Header, nil
+}
+
+func (c *httpCache) post(op, resource, key, action string, query url.Values, values []string, result any) error {
+	body, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(op, key, http.MethodPost, c.url(resource, key, action, query), bytes.NewReader(body), nil, result)
+	return err
+}
+
+// ttlHeader rounds TTLs away from zero, so they don't turn into 0 (no expiry)
+func ttlHeader(ttl time.Duration) http.Header {
+	ms := ttl.Milliseconds()
+	if ms == 0 && ttl > 0 {
+		ms = 1
+	} else if ms == 0 && ttl < 0 {
+		ms = -1
+	}
+	return http.Header{TTLHeader: []string{strconv.FormatInt(ms, 10)}}
+}
+
+func (c *httpCache) Set(key, value string, ttl time.Duration) error {
+	_, err := c.do("Set", key, http.MethodPut, c.url("keys", key, "", nil), strings.NewReader(value), ttlHeader(ttl), nil)
+	return err
+}
+
+func (c *httpCache) Get(key string) (value string, err error) {
+	_, err = c.do("Get", key, http.MethodGet, c.url("keys", key, "", nil), nil, nil, &value)
+	return
+}
+
+func (c *httpCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	_, err := c.do("SetBytes", key, http.MethodPut, c.url("keys", key, "", nil), bytes.NewReader(value), ttlHeader(ttl), nil)
+	return err
+}
+
+func (c *httpCache) GetBytes(key string) ([]byte, error) {
+	return c.AppendBytes(nil, key)
+}
+
+func (c *httpCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	value := dst
+	if _, err := c.do("AppendBytes", key, http.MethodGet, c.url("keys", key, "", nil), nil, nil, &value); err != nil {
+		return dst, err
+	}
+	return value, nil
+}
+
+func (c *httpCache) Del(key string) error {
+	_, err := c.do("Del", key, http.MethodDelete, c.url("keys", key, "", nil), nil, nil, nil)
+	return err
+}
+
+func (c *httpCache) GetTTL(key string) (time.Duration, error) {
+	header, err := c.do("GetTTL", key, http.MethodGet, c.url("ttl", key, "", nil), nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.ParseInt(header.Get(TTLHeader), 10, 64)
+	if err != nil {
+		return 0, &razcache.Error{Op: "GetTTL", Key: key, Backend: "http", Kind: razcache.ErrUnavailable, Err: err}
+	}
+	if ms < 0 {
+		return razcache.NoExpiry, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (c *httpCache) SetTTL(key string, ttl time.Duration) error {
+	_, err := c.do("SetTTL", key, http.MethodPut, c.url("ttl", key, "", nil), nil, ttlHeader(ttl), nil)
+	return err
+}
+
+func (c *httpCache) LPush(key string, values ...string) error {
+	return c.post("LPush", "lists", key, "lpush", nil, values, nil)
+}
+
+func (c *httpCache) RPush(key string, values ...string) error {
+	return c.post("RPush", "lists", key, "rpush", nil, values, nil)
+}
+
+func (c *httpCache) LPop(key string, count int) (values []string, err error) {
+	_, err = c.do("LPop", key, http.MethodPost, c.url("lists", key, "lpop", countQuery(count)), nil, nil, &values)
+	return
+}
+
+func (c *httpCache) RPop(key string, count int) (values []string, err error) {
+	_, err = c.do("RPop", key, http.MethodPost, c.url("lists", key, "rpop", countQuery(count)), nil, nil, &values)
+	return
+}
+
+func (c *httpCache) LLen(key string) (length int, err error) {
+	_, err = c.do("LLen", key, http.MethodGet, c.url("lists", key, "len", nil), nil, nil, &length)
+	return
+}
+
+func (c *httpCache) LRange(key string, start, stop int) (values []string, err error) {
+	query := url.Values{
+		"start": []string{strconv.Itoa(start)},
+		"stop":  []string{strconv.Itoa(stop)},
+	}
+	_, err = c.do("LRange", key, http.MethodGet, c.url("lists", key, "range", query), nil, nil, &values)
+	return
+}
+
+func (c *httpCache) SAdd(key string, values ...string) error {
+	return c.post("SAdd", "sets", key, "add", nil, values, nil)
+}
+
+func (c *httpCache) SRem(key string, values ...string) error {
+	return c.post("SRem", "sets", key, "rem", nil, values, nil)
+}
+
+func (c *httpCache) SHas(key, value string) (found bool, err error) {
+	query := url.Values{"value": []string{value}}
+	_, err = c.do("SHas", key, http.MethodGet, c.url("sets", key, "has", query), nil, nil, &found)
+	return
+}
+
+func (c *httpCache) SLen(key string) (length int, err error) {
+	_, err = c.do("SLen", key, http.MethodGet, c.url("sets", key, "len", nil), nil, nil, &length)
+	return
+}
+
+func (c *httpCache) SMembers(key string) (members []string, err error) {
+	_, err = c.do("SMembers", key, http.MethodGet, c.url("sets", key, "members", nil), nil, nil, &members)
+	return
+}
+
+func (c *httpCache) Incr(key string, increment int64) (value int64, err error) {
+	query := url.Values{"by": []string{strconv.FormatInt(increment, 10)}}
+	_, err = c.do("Incr", key, http.MethodPost, c.url("counters", key, "incr", query), nil, nil, &value)
+	return
+}
+
+func (c *httpCache) SubCache(prefix string) razcache.Cache {
+	return razcache.NewPrefixCache(c, prefix)
+}
+
+func (c *httpCache) SubExtendedCache(prefix string) razcache.ExtendedCache {
+	return razcache.NewPrefixExtendedCache(c, prefix)
+}
+
+// Close doesn't wait for requests in flight, but the ones after it fail with ErrCacheClosed
+func (c *httpCache) Close() error {
+	if !c.closed.Swap(true) && c.ownsClient {
+		c.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+func countQuery(count int) url.Values {
+	return url.Values{"count": []string{strconv.Itoa(count)}}
+}
+
+// translateHTTPError returns ErrNotFound and ErrWrongType as they are if the response
+// has their error code, and wraps other errors in a razcache.Error
+func translateHTTPError(op, key string, resp *http.Response) error {
+	var errResp errorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	switch {
+	case resp.StatusCode == http.StatusNotFound && errResp.Code == codeNotFound:
+		return razcache.ErrNotFound
+	case resp.StatusCode == http.StatusConflict && errResp.Code == codeWrongType:
+		return razcache.ErrWrongType
+	}
+	var kind error
+	switch resp.StatusCode {
+	case http.StatusRequestEntityTooLarge:
+		kind = razcache.ErrValueTooLarge
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		kind = razcache.ErrUnavailable
+	case http.StatusGatewayTimeout:
+		kind = razcache.ErrTimeout
+	case http.StatusNotImplemented:
+		kind = razcache.ErrNotSupported
+	}
+	err := errors.New(resp.Status)
+	if len(errResp.Error) > 0 {
+		err = errors.New(errResp.Error)
+	}
+	return requestError(op, key, kind, err)
+}
+
+func requestError(op, key string, kind, err error) error {
+	return &razcache.Error{Op: op, Key: key, Backend: "http", Kind: kind, Err: err}
+}
+
+// transportErrorKind classifies errors of sending requests and reading responses.
+// They are either timeouts, or the server can't be reached or sent a broken response.
+func transportErrorKind(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return razcache.ErrTimeout
+	}
+	return razcache.ErrUnavailable
+}
@@ -0,0 +1,260 @@
+package httpcache_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razzie/razcache"
+	. "github.com/razzie/razcache/pkg/httpcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func newHTTPCache(t *testing.T) (razcache.ExtendedCache, razcache.ExtendedCache) {
+	backend := inmem.NewInMemExtendedCache()
+	srv := httptest.NewServer(NewHandler(backend))
+	cache := NewHTTPCache(srv.URL)
+	t.Cleanup(func() {
+		cache.Close()
+		srv.Close()
+		backend.Close()
+	})
+	return cache, backend
+}
+
+func TestHTTPCacheBasic(t *testing.T) {
+	cache, _ := newHTTPCache(t)
+	testutil.TestBasic(t, cache)
+}
+
+func TestHTTPCacheTTL(t *testing.T) {
+	cache, _ := newHTTPCache(t)
+	testutil.TestTTL(t, cache, time.Millisecond*50)
+}
+
+func TestHTTPCacheLists(t *testing.T) {
+	cache, _ := newHTTPCache(t)
+	testutil.TestLists(t, cache)
+}
+
+func TestHTTPCacheSets(t *testing.T) {
+	cache, _ := newHTTPCache(t)
+	testutil.TestSets(t, cache)
+}
+
+func TestHTTPCacheIncr(t *testing.T) {
+	cache, _ := newHTTPCache(t)
+	testutil.TestIncr(t, cache)
+}
+
+func TestHTTPCacheKeys(t *testing.T) {
+	cache, backend := newHTTPCache(t)
+
+	// keys with special characters and binary values should round trip
+	key := "a/b?c d%2F#ü"
+	value := "line1\nline2\x00\xff"
+	assert.NoError(t, cache.Set(key, value, time.Minute))
+	result, err := backend.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, value, result)
+	result, err = cache.Get(key)
+	assert.NoError(t, err)
+	assert.Equal(t, value, result)
+
+	ttl, err := cache.GetTTL(key)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	assert.NoError(t, cache.SetTTL(key, time.Hour))
+	ttl, err = cache.GetTTL(key)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+	assert.NoError(t, cache.Del(key))
+	_, err = cache.GetTTL(key)
+	assert.Equal(t, razcache.ErrNotFound, err)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(inmem.NewInMemExtendedCache()))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/v2/keys/a", http.StatusNotFound},
+		{http.MethodGet, "/v1/keys/", http.StatusNotFound},
+		{http.MethodPost, "/v1/keys/a", http.StatusNotFound},
+		{http.MethodGet, "/v1/lists/a/nope", http.StatusNotFound},
+		{http.MethodPost, "/v1/counters/a/incr?by=x", http.StatusBadRequest},
+		{http.MethodPost, "/v1/lists/a/lpush", http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, nil)
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tc.status, resp.StatusCode, tc.method+" "+tc.path)
+	}
+}
+
+func TestHTTPCacheBackendErrors(t *testing.T) {
+	cache, backend := newHTTPCache(t)
+
+	// the kind of backend errors survives the round trip
+	assert.NoError(t, backend.Close())
+	_, err := cache.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrUnavailable)
+	var cacheErr *razcache.Error
+	assert.ErrorAs(t, err, &cacheErr)
+	assert.Equal(t, "http", cacheErr.Backend)
+	assert.Equal(t, "Get", cacheErr.Op)
+	assert.Equal(t, "key", cacheErr.Key)
+	assert.Contains(t, err.Error(), razcache.ErrCacheClosed.Error())
+}
+
+func TestHTTPCacheWrappedErrors(t *testing.T) {
+	// errors of backends wrap the sentinel errors
+	backend := razcache.NewMiddlewareExtendedCache(inmem.NewInMemExtendedCache(), func(next razcache.Handler) razcache.Handler {
+		return func(op *razcache.Operation) {
+			next(op)
+			if op.Err != nil {
+				op.Err = &razcache.Error{Op: op.Name, Backend: "test", Err: op.Err}
+			}
+		}
+	})
+	defer backend.Close()
+	srv := httptest.NewServer(NewHandler(backend))
+	defer srv.Close()
+	cache := NewHTTPCache(srv.URL)
+	defer cache.Close()
+
+	_, err := cache.Get("missing")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, cache.Set("str", "value", 0))
+	_, err = cache.LLen("str")
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func TestHTTPCacheTransportErrors(t *testing.T) {
+	// unreachable servers
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	cache := NewHTTPCache(srv.URL)
+	defer cache.Close()
+	_, err := cache.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrUnavailable)
+
+	// slow servers
+	release := make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	cache = NewHTTPCacheFromClient(srv.URL, &http.Client{Timeout: 50 * time.Millisecond})
+	_, err = cache.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrTimeout)
+
+	// broken responses
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer broken.Close()
+	cache = NewHTTPCache(broken.URL)
+	_, err = cache.LLen("list")
+	assert.ErrorIs(t, err, razcache.ErrUnavailable)
+	var cacheErr *razcache.Error
+	if assert.ErrorAs(t, err, &cacheErr) {
+		assert.Equal(t, "LLen", cacheErr.Op)
+		assert.Equal(t, "list", cacheErr.Key)
+	}
+	_, err = cache.GetTTL("key")
+	assert.ErrorIs(t, err, razcache.ErrUnavailable)
+
+	// closed clients
+	assert.NoError(t, cache.Close())
+	_, err = cache.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	assert.ErrorAs(t, err, &cacheErr)
+}
+
+func TestHTTPCacheRouterMiss(t *testing.T) {
+	backend := inmem.NewInMemExtendedCache()
+	defer backend.Close()
+	srv := httptest.NewServer(NewHandler(backend))
+	defer srv.Close()
+
+	// a wrong base URL isn't a cache miss
+	cache := NewHTTPCache(srv.URL + "/wrong")
+	defer cache.Close()
+	_, err := cache.Get("key")
+	assert.Error(t, err)
+	assert.NotEqual(t, razcache.ErrNotFound, err)
+	var cacheErr *razcache.Error
+	assert.ErrorAs(t, err, &cacheErr)
+
+	// neither is an empty key, which has no endpoint
+	cache = NewHTTPCache(srv.URL)
+	defer cache.Close()
+	err = cache.Set("", "value", 0)
+	assert.Error(t, err)
+	_, err = cache.Get("")
+	assert.Error(t, err)
+	assert.NotEqual(t, razcache.ErrNotFound, err)
+	_, err = cache.Get("missing")
+	assert.Equal(t, razcache.ErrNotFound, err)
+}
+
+func TestHTTPCacheBodyLimit(t *testing.T) {
+	cache, backend := newHTTPCache(t)
+
+	err := razcache.SetBytes(cache, "key", bytes.Repeat([]byte{'x'}, MaxBodySize+1), 0)
+	assert.ErrorIs(t, err, razcache.ErrValueTooLarge)
+	_, err = backend.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, razcache.SetBytes(cache, "key", bytes.Repeat([]byte{'x'}, MaxBodySize), 0))
+}
+
+// idleClosingTransport counts how many times its idle connections are closed
+type idleClosingTransport struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *idleClosingTransport) CloseIdleConnections() {
+	t.closed++
+}
+
+func TestHTTPCacheFromClientClose(t *testing.T) {
+	transport := &idleClosingTransport{RoundTripper: http.DefaultTransport}
+	cache := NewHTTPCacheFromClient("http://localhost", &http.Client{Transport: transport})
+
+	// the client belongs to the caller, so its connections are left alone
+	assert.NoError(t, cache.Close())
+	assert.Equal(t, 0, transport.closed)
+}
+
+func TestHTTPCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) razcache.Cache {
+		cache, _ := newHTTPCache(t)
+		return cache
+	})
+}
+
+func BenchmarkHTTPCache(b *testing.B) {
+	testutil.RunBenchmarks(b, func(b *testing.B) razcache.Cache {
+		backend := inmem.NewInMemExtendedCache()
+		srv := httptest.NewServer(NewHandler(backend))
+		b.Cleanup(func() {
+			srv.Close()
+			backend.Close()
+		})
+		return NewHTTPCache(srv.URL)
+	})
+}
@@ -0,0 +1,110 @@
+package inmem
+
+import (
+	"hash/maphash"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/razzie/razcache/pkg/inmem/internal"
+)
+
+// expiryShard is a heap of TTLs with its own lock, so writers of different keys rarely contend
+type expiryShard struct {
+	mu    sync.Mutex
+	queue internal.TTLQueue[string]
+}
+
+// expiry tracks the TTLs of keys in sharded heaps. Writers update the heaps directly,
+// and only wake the janitor if they add a TTL that is earlier than its next deadline.
+type expiry struct {
+	shards   []expiryShard
+	seed     maphash.Seed
+	deadline atomic.Int64 // unix nanoseconds of the janitor's next run
+	wake     chan struct{}
+}
+
+func newExpiry() *expiry {
+	// a power of two that is at least the number of cores
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n *= 2
+	}
+	e := &expiry{
+		shards: make([]expiryShard, n),
+		seed:   maphash.MakeSeed(),
+		wake:   make(chan struct{}, 1),
+	}
+	e.deadline.Store(math.MaxInt64)
+	return e
+}
+
+func (e *expiry) shard(key string) *expiryShard {
+	return &e.shards[maphash.String(e.seed, key)&uint64(len(e.shards)-1)]
+}
+
+// update replaces the old TTL data of an item with the new one.
+// Either can be nil. The new TTL data isn't added if the item has been updated again since.
+func (e *expiry) update(key string, item ttlData, oldTTL, newTTL *internal.TTLItem[string]) {
+	shard := e.shard(key)
+	shard.mu.Lock()
+	if oldTTL != nil {
+		shard.queue.Delete(oldTTL) // no-op if it was never pushed
+	}
+	push := newTTL != nil && item.LoadTTLData() == newTTL
+	if push {
+		shard.queue.PushItem(newTTL)
+	}
+	shard.mu.Unlock()
+
+	if push && newTTL.Expiration().UnixNano() < e.deadline.Load() {
+		select {
+		case e.wake <- struct{}{}:
+		default: // the janitor is already woken up
+		}
+	}
+}
+
+// expire pops the TTLs that are due and returns the earliest remaining expiration,
+// or the zero time if there's none
+func (e *expiry) expire(now time.Time, fn func(ttlData *internal.TTLItem[string])) time.Time {
+	// writers racing with this wake the janitor again instead of being missed
+	e.deadline.Store(math.MaxInt64)
+
+	var next time.Time
+	var expired []*internal.TTLItem[string]
+	for i := range e.shards {
+		shard := &e.shards[i]
+		shard.mu.Lock()
+		for shard.queue.Len() > 0 && !now.Before(shard.queue.Peek().Expiration()) {
+			expired = append(expired, shard.queue.Pop())
+		}
+		if shard.queue.Len() > 0 {
+			if exp := shard.queue.Peek().Expiration(); next.IsZero() || exp.Before(next) {
+				next = exp
+			}
+		}
+		shard.mu.Unlock()
+
+		for _, ttlData := range expired {
+			fn(ttlData)
+		}
+		expired = expired[:0]
+	}
+
+	if !next.IsZero() {
+		e.deadline.Store(next.UnixNano())
+	}
+	return next
+}
+
+func (e *expiry) clear() {
+	for i := range e.shards {
+		shard := &e.shards[i]
+		shard.mu.Lock()
+		shard.queue.Clear()
+		shard.mu.Unlock()
+	}
+}
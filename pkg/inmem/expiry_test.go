@@ -0,0 +1,87 @@
+package inmem
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/razzie/razcache/pkg/inmem/internal"
+)
+
+// channelExpiry is the previous design of expiry: writers send TTL updates
+// through a buffered channel to a single goroutine that owns the TTL queue
+type channelExpiry struct {
+	updates chan channelExpiryUpdate
+	queue   internal.TTLQueue[string]
+	done    chan struct{}
+}
+
+type channelExpiryUpdate struct {
+	item   ttlData
+	oldTTL *internal.TTLItem[string]
+	newTTL *internal.TTLItem[string]
+}
+
+func newChannelExpiry() *channelExpiry {
+	e := &channelExpiry{
+		updates: make(chan channelExpiryUpdate, 64),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(e.done)
+		for u := range e.updates {
+			if u.oldTTL != nil {
+				e.queue.Delete(u.oldTTL)
+			}
+			if u.newTTL != nil && u.item.LoadTTLData() == u.newTTL {
+				e.queue.PushItem(u.newTTL)
+			}
+		}
+	}()
+	return e
+}
+
+func (e *channelExpiry) update(key string, item ttlData, oldTTL, newTTL *internal.TTLItem[string]) {
+	e.updates <- channelExpiryUpdate{item: item, oldTTL: oldTTL, newTTL: newTTL}
+}
+
+func (e *channelExpiry) close() {
+	close(e.updates)
+	<-e.done
+}
+
+// BenchmarkExpiry compares the TTL updates of the channel and the sharded expiry designs.
+// Run it with -cpu 1,4,8 on a multi-core machine to see how they scale.
+func BenchmarkExpiry(b *testing.B) {
+	b.Run("Channel", func(b *testing.B) {
+		e := newChannelExpiry()
+		defer e.close()
+		benchmarkExpiry(b, e.update)
+	})
+	b.Run("Sharded", func(b *testing.B) {
+		benchmarkExpiry(b, newExpiry().update)
+	})
+}
+
+func benchmarkExpiry(b *testing.B, update func(key string, item ttlData, oldTTL, newTTL *internal.TTLItem[string])) {
+	const numKeys = 1024
+	keys := make([]string, numKeys)
+	items := make([]cacheItemBase, numKeys)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	now := time.Now()
+	var worker atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(worker.Add(1)) * 7919
+		for pb.Next() {
+			i++
+			key, item := keys[i%numKeys], &items[i%numKeys]
+			newTTL := internal.NewTTLItem(key, now.Add(time.Duration(i)*time.Millisecond))
+			oldTTL := item.SwapTTLData(newTTL)
+			update(key, item, oldTTL, newTTL)
+		}
+	})
+}
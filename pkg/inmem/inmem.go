@@ -15,9 +15,9 @@ type inMemCache struct {
 	inMemCacheBase[*cacheItem]
 }
 
-func NewInMemCache() razcache.Cache {
+func NewInMemCache(opts ...Option) razcache.Cache {
 	cache := new(inMemCache)
-	cache.init()
+	cache.init(opts)
 	return cache
 }
 
@@ -26,11 +26,41 @@ func (c *inMemCache) Set(key, value string, ttl time.Duration) error {
 	return c.set(key, item, ttl)
 }
 
+func (c *inMemCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return c.Set(key, string(value), ttl)
+}
+
+func (c *inMemCache) GetBytes(key string) ([]byte, error) {
+	return c.AppendBytes(nil, key)
+}
+
+func (c *inMemCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	item, err := c.get("AppendBytes", key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, item.value...), nil
+}
+
+func (c *inMemCache) SetKeepTTL(key, value string) error {
+	return c.setKeepTTL(key, &cacheItem{value: value})
+}
+
 func (c *inMemCache) Get(key string) (string, error) {
-	item, err := c.get(key)
+	item, err := c.get("Get", key)
+	if err != nil {
+		return "", err
+	}
+	return item.value, nil
+}
+
+// GetEx doesn't restart the idle TTL, as the new TTL replaces it anyway
+func (c *inMemCache) GetEx(key string, ttl time.Duration) (string, error) {
+	item, err := c.peek("GetEx", key)
 	if err != nil {
 		return "", err
 	}
+	c.setItemTTL(key, item, ttl)
 	return item.value, nil
 }
 
@@ -1,9 +1,15 @@
 package inmem_test
 
 import (
+	"runtime"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razzie/razcache"
 	. "github.com/razzie/razcache/pkg/inmem"
 	"github.com/razzie/razcache/pkg/testutil"
 )
@@ -19,3 +25,172 @@ func TestInMemTTL(t *testing.T) {
 	defer cache.Close()
 	testutil.TestTTL(t, cache, time.Millisecond*50)
 }
+
+func TestInMemTTLWithClock(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewInMemCache(WithClock(clock))
+	defer cache.Close()
+	testutil.TestTTLWithClock(t, cache, clock)
+
+	// remaining TTL follows the clock exactly
+	assert.NoError(t, cache.Set("key", "value", 10*time.Second))
+	clock.Advance(3 * time.Second)
+	ttl, err := cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*time.Second, ttl)
+}
+
+func TestInMemTTLChurn(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewInMemCache(WithClock(clock))
+	defer cache.Close()
+
+	// odd keys lose their TTL right after it's set, so the janitor must not delete them
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := strconv.Itoa(g*100 + i)
+				assert.NoError(t, cache.Set(key, key, time.Second*time.Duration(1+i%3)))
+				if i%2 == 1 {
+					assert.NoError(t, cache.SetTTL(key, 0))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for step := 1; step <= 3; step++ {
+		clock.Advance(time.Second)
+		for key := 0; key < 800; key++ {
+			i := key % 100
+			_, err := cache.Get(strconv.Itoa(key))
+			if i%2 == 1 || 1+i%3 > step {
+				assert.NoError(t, err, "key %d at step %d", key, step)
+			} else {
+				assert.Equal(t, razcache.ErrNotFound, err, "key %d at step %d", key, step)
+			}
+		}
+	}
+}
+
+func TestInMemMaxIdleTime(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewInMemCache(WithClock(clock), WithMaxIdleTime(time.Minute))
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("idle", "value", 0))
+	assert.NoError(t, cache.Set("read", "value", 0))
+	assert.NoError(t, cache.Set("ttl", "value", 150*time.Second))
+	ttl, err := cache.GetTTL("idle")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// reads keep idle keys alive, but GetTTL doesn't count as one
+	for i := 0; i < 3; i++ {
+		clock.Advance(40 * time.Second)
+		cache.GetTTL("idle")
+		_, err = cache.Get("read")
+		assert.NoError(t, err)
+		_, err = cache.Get("ttl")
+		assert.NoError(t, err)
+	}
+	_, err = cache.Get("idle")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	_, err = cache.Get("read")
+	assert.NoError(t, err)
+
+	// explicit TTLs aren't extended by reads
+	clock.Advance(40 * time.Second)
+	_, err = cache.Get("read")
+	assert.NoError(t, err)
+	_, err = cache.Get("ttl")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// persisting makes the key idle again
+	assert.NoError(t, cache.Set("key", "value", time.Second))
+	assert.NoError(t, razcache.Persist(cache, "key"))
+	ttl, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+}
+
+// assertNoLeaks fails if fn leaves goroutines behind
+func assertNoLeaks(t *testing.T, fn func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	fn()
+	// goroutines might still be returning after Close
+	after := runtime.NumGoroutine()
+	for deadline := time.Now().Add(time.Second); after > before && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	assert.LessOrEqual(t, after, before, "goroutines leaked")
+}
+
+func TestInMemClose(t *testing.T) {
+	assertNoLeaks(t, func() {
+		for i := 0; i < 100; i++ {
+			cache := NewInMemCache()
+			assert.NoError(t, cache.Set("key", "value", time.Millisecond))
+			assert.NoError(t, cache.Close())
+		}
+	})
+
+	// concurrent closes while the janitor is busy
+	assertNoLeaks(t, func() {
+		clock := testutil.NewFakeClock(time.Now())
+		cache := NewInMemCache(WithClock(clock))
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, cache.Set(strconv.Itoa(i), "value", time.Second))
+		}
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				clock.Advance(time.Second)
+				assert.NoError(t, cache.Close())
+			}()
+		}
+		wg.Wait()
+		assert.ErrorIs(t, cache.Set("key", "value", 0), razcache.ErrCacheClosed)
+	})
+}
+
+func TestInMemScan(t *testing.T) {
+	cache := NewInMemCache()
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("a:1", "1", 0))
+	assert.NoError(t, cache.Set("a:2", "2", 0))
+	assert.NoError(t, cache.Set("b:1", "1", 0))
+
+	keys, err := razcache.ScanKeys(cache, "a:")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a:1", "a:2"}, keys)
+
+	// stopping the scan early
+	var count int
+	assert.NoError(t, cache.(razcache.Scanner).Scan("", func(string) bool {
+		count++
+		return false
+	}))
+	assert.Equal(t, 1, count)
+}
+
+func TestInMemConformance(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	testutil.RunConformance(t, func(t *testing.T) razcache.Cache {
+		return NewInMemCache(WithClock(clock))
+	}, testutil.WithClock(clock))
+}
+
+func BenchmarkInMem(b *testing.B) {
+	testutil.RunBenchmarks(b, func(b *testing.B) razcache.Cache {
+		return NewInMemCache()
+	})
+}
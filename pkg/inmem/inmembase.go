@@ -1,8 +1,8 @@
 package inmem
 
 import (
-	"errors"
-	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,12 +11,8 @@ import (
 	"github.com/razzie/razcache/pkg/inmem/internal"
 )
 
-var (
-	// special pointer to mark not yet processed TTL data
-	ttlNotYetProcessed = &internal.TTLItem[string]{}
-
-	ErrCacheClosed = errors.New("cache is closed")
-)
+// Deprecated: ErrCacheClosed is razcache.ErrCacheClosed, which errors of closed caches match with errors.Is
+var ErrCacheClosed = razcache.ErrCacheClosed
 
 type ttlDataConstraint interface {
 	comparable
@@ -26,6 +22,8 @@ type ttlDataConstraint interface {
 type ttlData interface {
 	LoadTTLData() *internal.TTLItem[string]
 	StoreTTLData(*internal.TTLItem[string])
+	SwapTTLData(*internal.TTLItem[string]) *internal.TTLItem[string]
+	CompareAndSwapTTLData(old, new *internal.TTLItem[string]) bool
 }
 
 type cacheItemBase struct {
@@ -40,95 +38,96 @@ func (item *cacheItemBase) StoreTTLData(val *internal.TTLItem[string]) {
 	item.ttlData.Store(val)
 }
 
-type ttlUpdate struct {
-	key  string
-	item ttlData
-	exp  time.Time
+func (item *cacheItemBase) SwapTTLData(val *internal.TTLItem[string]) *internal.TTLItem[string] {
+	return item.ttlData.Swap(val)
+}
+
+func (item *cacheItemBase) CompareAndSwapTTLData(old, new *internal.TTLItem[string]) bool {
+	return item.ttlData.CompareAndSwap(old, new)
+}
+
+// expirationOf returns the expiration of TTL data, or the zero time if it's nil
+func expirationOf(ttlData *internal.TTLItem[string]) time.Time {
+	if ttlData == nil {
+		return time.Time{}
+	}
+	return ttlData.Expiration()
 }
 
 type inMemCacheBase[T ttlDataConstraint] struct {
-	items         atomic.Pointer[xsync.MapOf[string, T]]
-	ttlQueue      internal.TTLQueue[string]
-	ttlUpdateChan chan ttlUpdate
-	closedChan    chan struct{}
+	items       atomic.Pointer[xsync.MapOf[string, T]]
+	expiry      *expiry
+	closeOnce   sync.Once
+	closedChan  chan struct{}
+	janitorDone chan struct{}
+	clock       razcache.Clock
+	maxIdle     time.Duration
 }
 
-func (cache *inMemCacheBase[T]) init() {
+func (cache *inMemCacheBase[T]) init(opts []Option) {
+	o := newOptions(opts)
+	cache.clock = o.clock
+	cache.maxIdle = o.maxIdle
 	cache.items.Store(xsync.NewMapOf[string, T]())
-	cache.ttlUpdateChan = make(chan ttlUpdate, 64)
+	cache.expiry = newExpiry()
 	cache.closedChan = make(chan struct{})
+	cache.janitorDone = make(chan struct{})
 	go cache.janitor()
 }
 
+// janitor deletes expired keys until the cache is closed,
+// and it only checks if it's closed between two rounds of deleting keys
 func (c *inMemCacheBase[T]) janitor() {
-	timer := time.NewTimer(0)
-	var nextExp time.Time
+	defer close(c.janitorDone)
+	timer := c.clock.NewTimer(0)
 
 	defer func() {
-		close(c.ttlUpdateChan)
-
 		items := c.items.Swap(nil)
 		items.Clear()
+		c.expiry.clear()
 
-		if !timer.Stop() {
-			<-timer.C
-		}
+		stopTimer(timer)
 		timer = nil
 	}()
 
 	for {
 		select {
 		case <-c.closedChan:
-			c.ttlQueue.Clear()
 			return
+		case <-c.expiry.wake:
+		case <-timer.C():
+		}
+
+		now := c.clock.Now()
+		nextExp := c.expiry.expire(now, c.expireItem)
+		// reset timer to trigger when the next key expires
+		stopTimer(timer)
+		if !nextExp.IsZero() {
+			timer.Reset(nextExp.Sub(now))
+		}
+	}
+}
+
+func (c *inMemCacheBase[T]) expireItem(ttlData *internal.TTLItem[string]) {
+	key := ttlData.Value()
+	c.items.Load().Compute(key, func(oldValue T, loaded bool) (newValue T, delete bool) {
+		delete = !loaded || (loaded && oldValue.LoadTTLData() == ttlData)
+		newValue = oldValue
+		return
+	})
+}
 
-		case ttlUpdate := <-c.ttlUpdateChan:
-			ttlData := ttlUpdate.item.LoadTTLData()
-			if ttlData == ttlNotYetProcessed { // creating new TTL
-				ttlUpdate.item.StoreTTLData(c.ttlQueue.Push(ttlUpdate.key, ttlUpdate.exp))
-			} else { // updating TTL for existing item
-				if ttlUpdate.exp.IsZero() { // removing TTL
-					c.ttlQueue.Delete(ttlData)
-				} else { // updating TTL
-					c.ttlQueue.Update(ttlData, ttlUpdate.key, ttlUpdate.exp)
-				}
-			}
-			// update timer to trigger when the first key expires
-			if c.ttlQueue.Len() > 0 {
-				prevExp := nextExp
-				nextExp = c.ttlQueue.Peek().Expiration()
-				if nextExp.Before(prevExp) || prevExp.IsZero() {
-					if !timer.Stop() {
-						<-timer.C
-					}
-					timer.Reset(time.Until(nextExp))
-				}
-			} else {
-				if !timer.Stop() {
-					<-timer.C
-				}
-				nextExp = time.Time{}
-			}
-
-		case <-timer.C:
-			now := time.Now()
-			// check if the next items are about to expire
-			for c.ttlQueue.Len() > 0 && c.ttlQueue.Peek().Expiration().Before(now) {
-				ttlData := c.ttlQueue.Pop()
-				key := ttlData.Value()
-				c.items.Load().Compute(key, func(oldValue T, loaded bool) (newValue T, delete bool) {
-					delete = !loaded || (loaded && oldValue.LoadTTLData() == ttlData)
-					newValue = oldValue
-					return
-				})
-			}
-			// reset timer for next item in queue
-			if c.ttlQueue.Len() > 0 {
-				nextExp = c.ttlQueue.Peek().Expiration()
-				timer.Reset(time.Until(nextExp))
-			} else {
-				nextExp = time.Time{}
-			}
+// closedError is returned by the operations of closed caches
+func closedError(op, key string) error {
+	return &razcache.Error{Op: op, Key: key, Backend: "inmem", Kind: razcache.ErrCacheClosed, Err: razcache.ErrCacheClosed}
+}
+
+// stopTimer stops the timer and drains its channel if it has already fired
+func stopTimer(timer razcache.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C():
+		default:
 		}
 	}
 }
@@ -136,99 +135,251 @@ func (c *inMemCacheBase[T]) janitor() {
 func (c *inMemCacheBase[T]) set(key string, item T, ttl time.Duration) error {
 	items := c.items.Load()
 	if items == nil {
-		return ErrCacheClosed
+		return closedError("Set", key)
+	}
+	if ttl < 0 {
+		return c.Del(key)
 	}
-	items.Store(key, item)
-	if ttl != 0 {
-		item.StoreTTLData(ttlNotYetProcessed)
-		return c.sendTTLUpdate(key, item, ttl)
+	newTTL := c.newTTL(key, ttl)
+	if newTTL != nil {
+		item.StoreTTLData(newTTL)
+	}
+	old, loaded := items.LoadAndStore(key, item)
+	var oldTTL *internal.TTLItem[string]
+	if loaded {
+		oldTTL = old.LoadTTLData()
+	}
+	if newTTL != nil || oldTTL != nil {
+		c.expiry.update(key, item, oldTTL, newTTL)
 	}
 	return nil
 }
 
-func (c *inMemCacheBase[T]) get(key string) (item T, err error) {
+// setKeepTTL stores the item with the TTL of the item it replaces
+func (c *inMemCacheBase[T]) setKeepTTL(key string, item T) error {
+	items := c.items.Load()
+	if items == nil {
+		return closedError("SetKeepTTL", key)
+	}
+	now := c.clock.Now()
+	items.Compute(key, func(oldValue T, loaded bool) (newValue T, delete bool) {
+		if loaded && !c.expired(oldValue, now) {
+			item.StoreTTLData(oldValue.LoadTTLData())
+		}
+		return item, false
+	})
+	c.touch(key, item)
+	return nil
+}
+
+// newTTL returns the TTL data of a key that is set with the given TTL,
+// or nil if it shouldn't expire
+func (c *inMemCacheBase[T]) newTTL(key string, ttl time.Duration) *internal.TTLItem[string] {
+	switch {
+	case ttl > 0:
+		return internal.NewTTLItem(key, c.clock.Now().Add(ttl))
+	case c.maxIdle > 0:
+		return internal.NewSlidingTTLItem(key, c.clock.Now().Add(c.maxIdle))
+	default:
+		return nil
+	}
+}
+
+// touch restarts the idle TTL of an accessed item. Updating the TTL queue on every access
+// would be costly, so it's skipped until the TTL would move by 1/64 of the max idle time.
+func (c *inMemCacheBase[T]) touch(key string, item T) {
+	if c.maxIdle <= 0 {
+		return
+	}
+	oldTTL := item.LoadTTLData()
+	if oldTTL != nil && !oldTTL.Sliding() {
+		return
+	}
+	exp := c.clock.Now().Add(c.maxIdle)
+	if oldTTL != nil && exp.Sub(oldTTL.Expiration()) < c.maxIdle/64 {
+		return
+	}
+	newTTL := internal.NewSlidingTTLItem(key, exp)
+	if item.CompareAndSwapTTLData(oldTTL, newTTL) {
+		c.expiry.update(key, item, oldTTL, newTTL)
+	}
+}
+
+// get returns the item of the key and restarts its idle TTL
+func (c *inMemCacheBase[T]) get(op, key string) (item T, err error) {
+	if item, err = c.peek(op, key); err == nil {
+		c.touch(key, item)
+	}
+	return
+}
+
+// peek returns the item of the key without restarting its idle TTL
+func (c *inMemCacheBase[T]) peek(op, key string) (item T, err error) {
 	items := c.items.Load()
 	if items == nil {
-		err = ErrCacheClosed
+		err = closedError(op, key)
 		return
 	}
 	var ok bool
 	item, ok = items.Load(key)
-	if !ok {
+	if !ok || c.expired(item, c.clock.Now()) {
 		err = razcache.ErrNotFound
 	}
 	return
 }
 
-func (c *inMemCacheBase[T]) getOrCompute(key string, compute func() T) (item T, loaded bool, err error) {
+func (c *inMemCacheBase[T]) getOrCompute(op, key string, compute func() T) (item T, loaded bool, err error) {
 	items := c.items.Load()
 	if items == nil {
-		err = ErrCacheClosed
+		err = closedError(op, key)
+		return
+	}
+	item, loaded = items.Load(key)
+	if loaded && !c.expired(item, c.clock.Now()) {
+		c.touch(key, item)
 		return
 	}
-	item, loaded = items.LoadOrCompute(key, compute)
+	// the janitor will skip the TTL data of an expired item once it's replaced
+	item, _ = items.Compute(key, func(oldValue T, found bool) (newValue T, delete bool) {
+		if found && !c.expired(oldValue, c.clock.Now()) {
+			loaded = true
+			return oldValue, false
+		}
+		loaded = false
+		return compute(), false
+	})
+	c.touch(key, item)
 	return
 }
 
+// expired reports if the TTL of the item has passed, even if the janitor hasn't deleted it yet
+func (c *inMemCacheBase[T]) expired(item T, now time.Time) bool {
+	ttlData := item.LoadTTLData()
+	return ttlData != nil && !now.Before(ttlData.Expiration())
+}
+
 func (c *inMemCacheBase[T]) Del(key string) error {
 	items := c.items.Load()
 	if items == nil {
-		return ErrCacheClosed
+		return closedError("Del", key)
+	}
+	if item, loaded := items.LoadAndDelete(key); loaded {
+		if oldTTL := item.LoadTTLData(); oldTTL != nil {
+			c.expiry.update(key, item, oldTTL, nil)
+		}
+	}
+	return nil
+}
+
+// delIf deletes the key along with its TTL if it still holds the item and cond returns true
+func (c *inMemCacheBase[T]) delIf(key string, item T, cond func() bool) {
+	items := c.items.Load()
+	if items == nil {
+		return
+	}
+	var deleted bool
+	items.Compute(key, func(oldValue T, loaded bool) (newValue T, delete bool) {
+		deleted = loaded && oldValue == item && cond()
+		return oldValue, !loaded || deleted
+	})
+	if !deleted {
+		return
+	}
+	if oldTTL := item.LoadTTLData(); oldTTL != nil {
+		c.expiry.update(key, item, oldTTL, nil)
+	}
+}
+
+func (c *inMemCacheBase[T]) Scan(prefix string, fn func(key string) bool) error {
+	items := c.items.Load()
+	if items == nil {
+		return closedError("Scan", prefix)
 	}
-	items.Delete(key)
+	now := c.clock.Now()
+	items.Range(func(key string, item T) bool {
+		if strings.HasPrefix(key, prefix) && !c.expired(item, now) {
+			return fn(key)
+		}
+		return true
+	})
 	return nil
 }
 
 func (c *inMemCacheBase[T]) GetTTL(key string) (time.Duration, error) {
-	item, err := c.get(key)
+	item, err := c.peek("GetTTL", key)
 	if err != nil {
 		return 0, err
 	}
-	for {
-		ttlData := item.LoadTTLData()
-		switch ttlData {
-		case ttlNotYetProcessed:
-			if c.items.Load() == nil { // closed
-				return 0, nil
-			}
-			runtime.Gosched() // wait for janitor to assign ttl data
-		case nil:
-			return 0, nil
-		default:
-			return time.Until(ttlData.Expiration()), nil
+	if ttlData := item.LoadTTLData(); ttlData != nil {
+		// the key might expire between get and here, but its TTL shouldn't be negative
+		if ttl := ttlData.Expiration().Sub(c.clock.Now()); ttl > 0 {
+			return ttl, nil
 		}
+		return 0, razcache.ErrNotFound
 	}
+	return razcache.NoExpiry, nil
 }
 
 func (c *inMemCacheBase[T]) SetTTL(key string, ttl time.Duration) error {
-	item, err := c.get(key)
+	item, err := c.peek("SetTTL", key)
 	if err != nil {
 		return err
 	}
-	if ttl == 0 {
-		item.StoreTTLData(nil)
+	c.setItemTTL(key, item, ttl)
+	return nil
+}
+
+func (c *inMemCacheBase[T]) setItemTTL(key string, item T, ttl time.Duration) {
+	if ttl < 0 {
+		c.delIf(key, item, func() bool { return true })
+		return
+	}
+	newTTL := c.newTTL(key, ttl)
+	oldTTL := item.SwapTTLData(newTTL)
+	if newTTL != nil || oldTTL != nil {
+		c.expiry.update(key, item, oldTTL, newTTL)
 	}
-	return c.sendTTLUpdate(key, item, ttl)
 }
 
-func (c *inMemCacheBase[T]) sendTTLUpdate(key string, item T, ttl time.Duration) error {
-	var exp time.Time
-	if ttl > 0 {
-		exp = time.Now().Add(ttl)
+func (c *inMemCacheBase[T]) Expire(key string, ttl time.Duration, cond razcache.ExpireCondition) (bool, error) {
+	return c.ExpireAt(key, c.clock.Now().Add(ttl), cond)
+}
+
+func (c *inMemCacheBase[T]) ExpireAt(key string, deadline time.Time, cond razcache.ExpireCondition) (bool, error) {
+	item, err := c.peek("ExpireAt", key)
+	if err != nil {
+		return false, err
 	}
-	select {
-	case <-c.closedChan:
-		return ErrCacheClosed
-	case c.ttlUpdateChan <- ttlUpdate{
-		key:  key,
-		item: item,
-		exp:  exp,
-	}:
-		return nil
+	if !deadline.After(c.clock.Now()) {
+		var deleted bool
+		c.delIf(key, item, func() bool {
+			deleted = cond.Allows(expirationOf(item.LoadTTLData()), deadline)
+			return deleted
+		})
+		return deleted, nil
 	}
+	newTTL := internal.NewTTLItem(key, deadline)
+	for {
+		oldTTL := item.LoadTTLData()
+		if !cond.Allows(expirationOf(oldTTL), deadline) {
+			return false, nil
+		}
+		if item.CompareAndSwapTTLData(oldTTL, newTTL) {
+			c.expiry.update(key, item, oldTTL, newTTL)
+			return true, nil
+		}
+	}
+}
+
+func (c *inMemCacheBase[T]) Persist(key string) error {
+	return c.SetTTL(key, 0)
 }
 
+// Close waits for the janitor to finish, then releases the items
 func (c *inMemCacheBase[T]) Close() error {
-	close(c.closedChan)
+	c.closeOnce.Do(func() {
+		close(c.closedChan)
+		<-c.janitorDone
+	})
 	return nil
 }
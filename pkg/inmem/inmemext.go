@@ -1,14 +1,13 @@
 package inmem
 
 import (
+	"errors"
 	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/razzie/razcache"
 	"github.com/razzie/razcache/pkg/inmem/internal"
-
-	"github.com/puzpuzpuz/xsync/v3"
 )
 
 type extCacheItem struct {
@@ -31,13 +30,24 @@ func (item *extCacheItem) getValue() any {
 	return *value
 }
 
+func (item *extCacheItem) stringValue() (string, error) {
+	switch value := item.getValue().(type) {
+	case string:
+		return value, nil
+	case *int64:
+		return strconv.FormatInt(atomic.LoadInt64(value), 10), nil
+	default:
+		return "", razcache.ErrWrongType
+	}
+}
+
 type inMemExtCache struct {
 	inMemCacheBase[*extCacheItem]
 }
 
-func NewInMemExtendedCache() razcache.ExtendedCache {
+func NewInMemExtendedCache(opts ...Option) razcache.ExtendedCache {
 	cache := new(inMemExtCache)
-	cache.init()
+	cache.init(opts)
 	return cache
 }
 
@@ -46,138 +56,243 @@ func (c *inMemExtCache) Set(key, value string, ttl time.Duration) error {
 	return c.set(key, item, ttl)
 }
 
-func (c *inMemExtCache) Get(key string) (string, error) {
-	item, err := c.get(key)
+func (c *inMemExtCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return c.Set(key, string(value), ttl)
+}
+
+func (c *inMemExtCache) GetBytes(key string) ([]byte, error) {
+	return c.AppendBytes(nil, key)
+}
+
+func (c *inMemExtCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	item, err := c.get("AppendBytes", key)
 	if err != nil {
-		return "", err
+		return dst, err
 	}
 	switch value := item.getValue().(type) {
 	case string:
-		return value, nil
+		return append(dst, value...), nil
 	case *int64:
-		return strconv.FormatInt(*value, 10), nil
+		return strconv.AppendInt(dst, atomic.LoadInt64(value), 10), nil
 	default:
-		return "", razcache.ErrWrongType
+		return dst, razcache.ErrWrongType
 	}
 }
 
-func (c *inMemExtCache) getList(key string) (*internal.List[string], error) {
-	item, _, err := c.getOrCompute(key, func() *extCacheItem {
-		return newExtCacheItem(new(internal.List[string]))
-	})
+func (c *inMemExtCache) SetKeepTTL(key, value string) error {
+	return c.setKeepTTL(key, newExtCacheItem(value))
+}
+
+func (c *inMemExtCache) Get(key string) (string, error) {
+	item, err := c.get("Get", key)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return item.stringValue()
+}
+
+// GetEx doesn't change the TTL of keys of the wrong type
+func (c *inMemExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	item, err := c.peek("GetEx", key)
+	if err != nil {
+		return "", err
+	}
+	value, err := item.stringValue()
+	if err != nil {
+		return "", err
+	}
+	c.setItemTTL(key, item, ttl)
+	return value, nil
+}
+
+// getList returns the list of the key, or nil if the key doesn't exist
+func (c *inMemExtCache) getList(op, key string) (*extCacheItem, *internal.List[string], error) {
+	item, err := c.get(op, key)
+	if err != nil {
+		if errors.Is(err, razcache.ErrNotFound) {
+			err = nil
+		}
+		return nil, nil, err
 	}
 	if value, ok := item.getValue().(*internal.List[string]); ok {
-		return value, nil
+		return item, value, nil
 	}
-	return nil, razcache.ErrWrongType
+	return nil, nil, razcache.ErrWrongType
+}
+
+func (c *inMemExtCache) push(op, key string, push func(list *internal.List[string]) bool) error {
+	for {
+		item, _, err := c.getOrCompute(op, key, func() *extCacheItem {
+			return newExtCacheItem(new(internal.List[string]))
+		})
+		if err != nil {
+			return err
+		}
+		list, ok := item.getValue().(*internal.List[string])
+		if !ok {
+			return razcache.ErrWrongType
+		}
+		// retry if the list has been emptied and deleted in the meantime
+		if push(list) {
+			return nil
+		}
+	}
+}
+
+func (c *inMemExtCache) pop(op, key string, pop func(list *internal.List[string]) []string) ([]string, error) {
+	item, list, err := c.getList(op, key)
+	if list == nil {
+		return nil, err
+	}
+	values := pop(list)
+	if len(values) > 0 {
+		c.delIf(key, item, list.Discard)
+	}
+	return values, nil
 }
 
 func (c *inMemExtCache) LPush(key string, values ...string) error {
-	list, err := c.getList(key)
-	if err != nil {
+	if len(values) == 0 {
+		_, _, err := c.getList("LPush", key)
 		return err
 	}
-	list.PushFront(values...)
-	return nil
+	return c.push("LPush", key, func(list *internal.List[string]) bool {
+		return list.PushFront(values...)
+	})
 }
 
 func (c *inMemExtCache) RPush(key string, values ...string) error {
-	list, err := c.getList(key)
-	if err != nil {
+	if len(values) == 0 {
+		_, _, err := c.getList("RPush", key)
 		return err
 	}
-	list.PushBack(values...)
-	return nil
+	return c.push("RPush", key, func(list *internal.List[string]) bool {
+		return list.PushBack(values...)
+	})
 }
 
 func (c *inMemExtCache) LPop(key string, count int) ([]string, error) {
-	list, err := c.getList(key)
-	if err != nil {
-		return nil, err
-	}
-	return list.PopFront(count), nil
+	return c.pop("LPop", key, func(list *internal.List[string]) []string {
+		return list.PopFront(count)
+	})
 }
 
 func (c *inMemExtCache) RPop(key string, count int) ([]string, error) {
-	list, err := c.getList(key)
-	if err != nil {
-		return nil, err
-	}
-	return list.PopBack(count), nil
+	return c.pop("RPop", key, func(list *internal.List[string]) []string {
+		return list.PopBack(count)
+	})
 }
 
 func (c *inMemExtCache) LLen(key string) (int, error) {
-	list, err := c.getList(key)
-	if err != nil {
+	_, list, err := c.getList("LLen", key)
+	if list == nil {
 		return 0, err
 	}
 	return list.Len(), nil
 }
 
 func (c *inMemExtCache) LRange(key string, start, stop int) ([]string, error) {
-	list, err := c.getList(key)
-	if err != nil {
+	_, list, err := c.getList("LRange", key)
+	if list == nil {
 		return nil, err
 	}
 	return list.Range(start, stop), nil
 }
 
-func (c *inMemExtCache) getSet(key string) (*xsync.Map, error) {
-	item, _, err := c.getOrCompute(key, func() *extCacheItem {
-		return newExtCacheItem(xsync.NewMap())
-	})
+// getSet returns the set of the key, or nil if the key doesn't exist
+func (c *inMemExtCache) getSet(op, key string) (*extCacheItem, *internal.Set[string], error) {
+	item, err := c.get(op, key)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, razcache.ErrNotFound) {
+			err = nil
+		}
+		return nil, nil, err
 	}
-	if value, ok := item.getValue().(*xsync.Map); ok {
-		return value, nil
+	if value, ok := item.getValue().(*internal.Set[string]); ok {
+		return item, value, nil
 	}
-	return nil, razcache.ErrWrongType
+	return nil, nil, razcache.ErrWrongType
 }
 
 func (c *inMemExtCache) SAdd(key string, values ...string) error {
-	set, err := c.getSet(key)
-	if err != nil {
-		return err
+	_, err := c.sAdd("SAdd", key, values)
+	return err
+}
+
+func (c *inMemExtCache) SAddCount(key string, values ...string) (int, error) {
+	return c.sAdd("SAddCount", key, values)
+}
+
+func (c *inMemExtCache) sAdd(op, key string, values []string) (int, error) {
+	if len(values) == 0 {
+		_, _, err := c.getSet(op, key)
+		return 0, err
 	}
-	for _, value := range values {
-		set.Store(value, true)
+	for {
+		item, _, err := c.getOrCompute(op, key, func() *extCacheItem {
+			return newExtCacheItem(internal.NewSet[string]())
+		})
+		if err != nil {
+			return 0, err
+		}
+		set, ok := item.getValue().(*internal.Set[string])
+		if !ok {
+			return 0, razcache.ErrWrongType
+		}
+		// retry if the set has been emptied and deleted in the meantime
+		if added, ok := set.Add(values...); ok {
+			return added, nil
+		}
 	}
-	return nil
 }
 
 func (c *inMemExtCache) SRem(key string, values ...string) error {
-	set, err := c.getSet(key)
-	if err != nil {
-		return err
+	_, err := c.sRem("SRem", key, values)
+	return err
+}
+
+func (c *inMemExtCache) SRemCount(key string, values ...string) (int, error) {
+	return c.sRem("SRemCount", key, values)
+}
+
+func (c *inMemExtCache) sRem(op, key string, values []string) (int, error) {
+	item, set, err := c.getSet(op, key)
+	if set == nil || len(values) == 0 {
+		return 0, err
 	}
-	for _, value := range values {
-		set.Delete(value)
+	removed, remaining := set.Remove(values...)
+	if remaining == 0 {
+		c.delIf(key, item, set.Discard)
 	}
-	return nil
+	return removed, nil
 }
 
 func (c *inMemExtCache) SHas(key, value string) (bool, error) {
-	set, err := c.getSet(key)
-	if err != nil {
+	_, set, err := c.getSet("SHas", key)
+	if set == nil {
 		return false, err
 	}
-	_, found := set.Load(value)
-	return found, nil
+	return set.Has(value), nil
 }
 
 func (c *inMemExtCache) SLen(key string) (int, error) {
-	set, err := c.getSet(key)
-	if err != nil {
+	_, set, err := c.getSet("SLen", key)
+	if set == nil {
 		return 0, err
 	}
-	return set.Size(), nil
+	return set.Len(), nil
+}
+
+func (c *inMemExtCache) SMembers(key string) ([]string, error) {
+	_, set, err := c.getSet("SMembers", key)
+	if set == nil {
+		return nil, err
+	}
+	return set.Members(), nil
 }
 
 func (c *inMemExtCache) Incr(key string, increment int64) (int64, error) {
-	item, loaded, err := c.getOrCompute(key, func() *extCacheItem {
+	item, loaded, err := c.getOrCompute("Incr", key, func() *extCacheItem {
 		return newExtCacheItem(&increment)
 	})
 	if err != nil {
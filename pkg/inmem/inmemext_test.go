@@ -4,6 +4,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razzie/razcache"
 	. "github.com/razzie/razcache/pkg/inmem"
 	"github.com/razzie/razcache/pkg/testutil"
 )
@@ -20,6 +23,49 @@ func TestInMemExtTTL(t *testing.T) {
 	testutil.TestTTL(t, cache, time.Millisecond*50)
 }
 
+// janitorlessClock never fires the timers of the janitor, so only reads can expire keys
+type janitorlessClock struct {
+	*testutil.FakeClock
+	timers *testutil.FakeClock
+}
+
+func (c janitorlessClock) NewTimer(d time.Duration) razcache.Timer {
+	return c.timers.NewTimer(d)
+}
+
+func TestInMemExtLazyExpiry(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewInMemExtendedCache(WithClock(janitorlessClock{clock, testutil.NewFakeClock(time.Now())}))
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("str", "value", time.Second))
+	assert.NoError(t, cache.RPush("list", "a", "b"))
+	assert.NoError(t, cache.SetTTL("list", time.Second))
+	assert.NoError(t, cache.Set("counter", "5", time.Second))
+	clock.Advance(time.Second)
+
+	_, err := cache.Get("str")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	_, err = cache.GetTTL("str")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.Equal(t, razcache.ErrNotFound, cache.SetTTL("str", time.Second))
+	keys, err := razcache.ScanKeys(cache, "")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// expired keys are replaced by writes
+	assert.NoError(t, cache.RPush("list", "c"))
+	values, err := cache.LRange("list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, values)
+	ttl, err := cache.GetTTL("list")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+	value, err := cache.Incr("counter", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+}
+
 func TestInMemExtLists(t *testing.T) {
 	cache := NewInMemExtendedCache()
 	defer cache.Close()
@@ -37,3 +83,35 @@ func TestInMemExtIncr(t *testing.T) {
 	defer cache.Close()
 	testutil.TestIncr(t, cache)
 }
+
+func TestInMemExtClose(t *testing.T) {
+	assertNoLeaks(t, func() {
+		for i := 0; i < 100; i++ {
+			cache := NewInMemExtendedCache(WithMaxIdleTime(time.Millisecond))
+			assert.NoError(t, cache.RPush("list", "a"))
+			assert.NoError(t, cache.SAdd("set", "a"))
+			assert.NoError(t, cache.Close())
+			assert.NoError(t, cache.Close())
+			_, err := cache.LLen("list")
+			assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+			var cacheErr *razcache.Error
+			if assert.ErrorAs(t, err, &cacheErr) {
+				assert.Equal(t, "LLen", cacheErr.Op)
+				assert.Equal(t, "list", cacheErr.Key)
+			}
+		}
+	})
+}
+
+func TestInMemExtConformance(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	testutil.RunConformance(t, func(t *testing.T) razcache.Cache {
+		return NewInMemExtendedCache(WithClock(clock))
+	}, testutil.WithClock(clock))
+}
+
+func BenchmarkInMemExt(b *testing.B) {
+	testutil.RunBenchmarks(b, func(b *testing.B) razcache.Cache {
+		return NewInMemExtendedCache()
+	})
+}
@@ -4,84 +4,194 @@ import (
 	"sync"
 )
 
+const listChunkSize = 64
+
+type listChunk[T any] [listChunkSize]T
+
+// List is a deque of fixed size chunks kept in a ring buffer, so pushes and pops
+// are amortized O(1) at both ends. Returned slices are copies, they don't alias the list.
 type List[T any] struct {
-	mu    sync.Mutex
-	slice []T
+	mu        sync.Mutex
+	discarded bool
+	chunks    []*listChunk[T] // ring buffer of chunks
+	first     int             // index of the first chunk in the ring
+	nchunks   int             // number of chunks in use
+	head      int             // index of the first value in the first chunk
+	len       int
+}
+
+// at returns the address of the i-th value of the list
+func (l *List[T]) at(i int) *T {
+	pos := l.head + i
+	chunk := l.chunks[(l.first+pos/listChunkSize)%len(l.chunks)]
+	return &chunk[pos%listChunkSize]
+}
+
+// grow makes room for one more chunk in the ring
+func (l *List[T]) grow() {
+	if l.nchunks < len(l.chunks) {
+		return
+	}
+	chunks := make([]*listChunk[T], max(2*len(l.chunks), 1))
+	for i := 0; i < l.nchunks; i++ {
+		chunks[i] = l.chunks[(l.first+i)%len(l.chunks)]
+	}
+	l.chunks = chunks
+	l.first = 0
 }
 
-func (l *List[T]) PushFront(values ...T) {
+// Discard marks an empty list as discarded and returns true, or returns false if it isn't empty
+func (l *List[T]) Discard() bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.slice = append(values, l.slice...)
+	if l.len > 0 {
+		return false
+	}
+	l.discarded = true
+	return true
 }
 
-func (l *List[T]) PopFront(count int) []T {
+// PushFront returns false if the list is discarded
+func (l *List[T]) PushFront(values ...T) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if count < 0 {
-		return nil
+	if l.discarded {
+		return false
 	}
 
-	count = min(count, len(l.slice))
-	if count == 0 {
-		return nil
+	// pushing in reverse keeps the order of values
+	for i := len(values) - 1; i >= 0; i-- {
+		if l.head == 0 {
+			l.grow()
+			l.first = (l.first - 1 + len(l.chunks)) % len(l.chunks)
+			l.chunks[l.first] = new(listChunk[T])
+			l.nchunks++
+			l.head = listChunkSize
+		}
+		l.head--
+		l.len++
+		*l.at(0) = values[i]
 	}
-
-	values := l.slice[0:count]
-	l.slice = l.slice[count:]
-	return values
+	return true
 }
 
-func (l *List[T]) PushBack(values ...T) {
+// PushBack returns false if the list is discarded
+func (l *List[T]) PushBack(values ...T) bool {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.slice = append(l.slice, values...)
+
+	if l.discarded {
+		return false
+	}
+
+	for _, value := range values {
+		if l.head+l.len == l.nchunks*listChunkSize {
+			l.grow()
+			l.chunks[(l.first+l.nchunks)%len(l.chunks)] = new(listChunk[T])
+			l.nchunks++
+		}
+		l.len++
+		*l.at(l.len - 1) = value
+	}
+	return true
 }
 
-func (l *List[T]) PopBack(count int) []T {
+func (l *List[T]) PopFront(count int) []T {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if count < 0 {
+	count = min(count, l.len)
+	if count <= 0 {
 		return nil
 	}
 
-	len := len(l.slice)
+	values := l.copyRange(0, count)
+	l.clearRange(0, count)
+	l.head += count
+	l.len -= count
+	// release the chunks before the new head
+	for l.head >= listChunkSize {
+		l.chunks[l.first] = nil
+		l.first = (l.first + 1) % len(l.chunks)
+		l.nchunks--
+		l.head -= listChunkSize
+	}
+	l.shrink()
+	return values
+}
+
+func (l *List[T]) PopBack(count int) []T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	count = min(count, len)
-	if count == 0 {
+	count = min(count, l.len)
+	if count <= 0 {
 		return nil
 	}
 
-	values := l.slice[len-count:]
-	l.slice = l.slice[:len-count]
+	values := l.copyRange(l.len-count, l.len)
+	l.clearRange(l.len-count, l.len)
+	l.len -= count
+	// release the chunks after the new tail
+	for l.nchunks > 0 && l.head+l.len <= (l.nchunks-1)*listChunkSize {
+		l.nchunks--
+		l.chunks[(l.first+l.nchunks)%len(l.chunks)] = nil
+	}
+	l.shrink()
 	return values
 }
 
+// shrink releases the last chunk of an empty list
+func (l *List[T]) shrink() {
+	if l.len > 0 {
+		return
+	}
+	for i := range l.chunks {
+		l.chunks[i] = nil
+	}
+	l.first = 0
+	l.nchunks = 0
+	l.head = 0
+}
+
 func (l *List[T]) Len() int {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return len(l.slice)
+	return l.len
 }
 
 func (l *List[T]) Range(start, stop int) []T {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	len := len(l.slice)
-
 	if start < 0 {
-		start += len
+		start = max(start+l.len, 0)
 	}
 	if stop < 0 {
-		stop += len
+		stop += l.len
 	}
+	stop = min(stop, l.len-1)
 
-	if start >= len {
+	if start > stop {
 		return nil
 	}
-	stop = min(stop, len-1)
 
-	return l.slice[start : stop+1]
+	return l.copyRange(start, stop+1)
+}
+
+func (l *List[T]) copyRange(start, end int) []T {
+	values := make([]T, end-start)
+	for i := range values {
+		values[i] = *l.at(start + i)
+	}
+	return values
+}
+
+// clearRange zeroes the values, so the list doesn't keep them alive
+func (l *List[T]) clearRange(start, end int) {
+	var zero T
+	for i := start; i < end; i++ {
+		*l.at(i) = zero
+	}
 }
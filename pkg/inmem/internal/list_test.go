@@ -0,0 +1,124 @@
+package internal_test
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache/pkg/inmem/internal"
+)
+
+func TestListMatchesSlice(t *testing.T) {
+	var list List[int]
+	var model []int
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		values := make([]int, rnd.Intn(200))
+		for j := range values {
+			values[j] = i*1000 + j
+		}
+		count := rnd.Intn(300)
+		switch rnd.Intn(4) {
+		case 0:
+			list.PushFront(values...)
+			model = append(append([]int(nil), values...), model...)
+		case 1:
+			list.PushBack(values...)
+			model = append(model, values...)
+		case 2:
+			count = min(count, len(model))
+			popped := list.PopFront(count)
+			if count == 0 {
+				assert.Empty(t, popped)
+			} else {
+				assert.Equal(t, model[:count], popped)
+			}
+			model = model[count:]
+		case 3:
+			count = min(count, len(model))
+			popped := list.PopBack(count)
+			if count == 0 {
+				assert.Empty(t, popped)
+			} else {
+				assert.Equal(t, model[len(model)-count:], popped)
+			}
+			model = model[:len(model)-count]
+		}
+		if !assert.Equal(t, len(model), list.Len()) {
+			return
+		}
+	}
+	if len(model) > 0 {
+		assert.Equal(t, model, list.Range(0, -1))
+	}
+}
+
+func TestListNoAliasing(t *testing.T) {
+	var list List[string]
+	list.PushBack("a", "b", "c")
+
+	popped := list.PopBack(2)
+	values := list.Range(0, -1)
+	list.PushBack("x", "y")
+	list.PushFront("z")
+	values[0] = "changed"
+
+	assert.Equal(t, []string{"b", "c"}, popped)
+	assert.Equal(t, []string{"changed"}, values)
+	assert.Equal(t, []string{"z", "a", "x", "y"}, list.Range(0, -1))
+}
+
+func TestListConcurrentAliasing(t *testing.T) {
+	// run with -race: returned slices must not be written by later pushes
+	var list List[string]
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				list.PushBack(strconv.Itoa(i))
+				list.PushFront(strconv.Itoa(i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				for _, values := range [][]string{list.PopFront(2), list.PopBack(2), list.Range(0, 3)} {
+					for _, value := range values {
+						_, err := strconv.Atoi(value)
+						assert.NoError(t, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkListPushFront(b *testing.B) {
+	var list List[string]
+	for i := 0; i < b.N; i++ {
+		list.PushFront("value")
+	}
+}
+
+func BenchmarkListPushBack(b *testing.B) {
+	var list List[string]
+	for i := 0; i < b.N; i++ {
+		list.PushBack("value")
+	}
+}
+
+func BenchmarkListPushPop(b *testing.B) {
+	var list List[string]
+	list.PushBack(make([]string, 10000)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.PushFront("value")
+		list.PopBack(1)
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"sync"
+)
+
+// Set is a set of values that can be discarded once empty, like List
+type Set[T comparable] struct {
+	mu        sync.RWMutex
+	discarded bool
+	values    map[T]struct{}
+}
+
+func NewSet[T comparable]() *Set[T] {
+	return &Set[T]{values: make(map[T]struct{})}
+}
+
+// Discard marks an empty set as discarded and returns true, or returns false if it isn't empty
+func (s *Set[T]) Discard() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.values) > 0 {
+		return false
+	}
+	s.discarded = true
+	return true
+}
+
+// Add returns the number of added values, or false if the set is discarded
+func (s *Set[T]) Add(values ...T) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discarded {
+		return 0, false
+	}
+	length := len(s.values)
+	for _, value := range values {
+		s.values[value] = struct{}{}
+	}
+	return len(s.values) - length, true
+}
+
+// Remove returns the number of removed and remaining values
+func (s *Set[T]) Remove(values ...T) (removed, remaining int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	length := len(s.values)
+	for _, value := range values {
+		delete(s.values, value)
+	}
+	return length - len(s.values), len(s.values)
+}
+
+func (s *Set[T]) Has(value T) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, found := s.values[value]
+	return found
+}
+
+func (s *Set[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.values)
+}
+
+func (s *Set[T]) Members() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	members := make([]T, 0, len(s.values))
+	for value := range s.values {
+		members = append(members, value)
+	}
+	return members
+}
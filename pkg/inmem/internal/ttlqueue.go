@@ -8,17 +8,23 @@ import (
 type TTLItem[T any] struct {
 	value      T
 	expiration time.Time
+	sliding    bool
 	index      int
 }
 
-func (i TTLItem[T]) Value() T {
+func (i *TTLItem[T]) Value() T {
 	return i.value
 }
 
-func (i TTLItem[T]) Expiration() time.Time {
+func (i *TTLItem[T]) Expiration() time.Time {
 	return i.expiration
 }
 
+// Sliding reports if the item was created by NewSlidingTTLItem
+func (i *TTLItem[T]) Sliding() bool {
+	return i.sliding
+}
+
 type ttlQueueImpl[T any] []*TTLItem[T]
 
 func (ttlq ttlQueueImpl[T]) Len() int { return len(ttlq) }
@@ -54,15 +60,34 @@ type TTLQueue[T any] struct {
 	q ttlQueueImpl[T]
 }
 
-func (ttlq *TTLQueue[T]) Push(value T, expiration time.Time) *TTLItem[T] {
-	item := &TTLItem[T]{
+// NewTTLItem returns an item that is not yet in any queue
+func NewTTLItem[T any](value T, expiration time.Time) *TTLItem[T] {
+	return &TTLItem[T]{
 		value:      value,
 		expiration: expiration,
+		index:      -1,
 	}
+}
+
+// NewSlidingTTLItem is like NewTTLItem, but marks the item as sliding,
+// so its owner knows to replace it with a later one when the value is accessed
+func NewSlidingTTLItem[T any](value T, expiration time.Time) *TTLItem[T] {
+	item := NewTTLItem(value, expiration)
+	item.sliding = true
+	return item
+}
+
+func (ttlq *TTLQueue[T]) Push(value T, expiration time.Time) *TTLItem[T] {
+	item := NewTTLItem(value, expiration)
 	heap.Push(&ttlq.q, item)
 	return item
 }
 
+// PushItem pushes an item created by NewTTLItem
+func (ttlq *TTLQueue[T]) PushItem(item *TTLItem[T]) {
+	heap.Push(&ttlq.q, item)
+}
+
 func (ttlq *TTLQueue[T]) Pop() *TTLItem[T] {
 	return heap.Pop(&ttlq.q).(*TTLItem[T])
 }
@@ -74,10 +99,17 @@ func (ttlq *TTLQueue[T]) Peek() *TTLItem[T] {
 func (ttlq *TTLQueue[T]) Update(item *TTLItem[T], value T, expiration time.Time) {
 	item.value = value
 	item.expiration = expiration
+	if item.index < 0 { // already popped or deleted
+		heap.Push(&ttlq.q, item)
+		return
+	}
 	heap.Fix(&ttlq.q, item.index)
 }
 
 func (ttlq *TTLQueue[T]) Delete(item *TTLItem[T]) {
+	if item.index < 0 { // already popped or deleted
+		return
+	}
 	heap.Remove(&ttlq.q, item.index)
 }
 
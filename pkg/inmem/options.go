@@ -0,0 +1,39 @@
+package inmem
+
+import (
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+// Option configures an in-memory cache
+type Option func(o *options)
+
+type options struct {
+	clock   razcache.Clock
+	maxIdle time.Duration
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: razcache.SystemClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithClock sets the clock of TTLs (razcache.SystemClock by default)
+func WithClock(clock razcache.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithMaxIdleTime makes keys without TTL expire once they haven't been accessed for the given time.
+// Their TTL restarts whenever they are read or written, and it's reported by GetTTL like any other TTL.
+// Persist and SetTTL with a TTL of 0 make keys idle again instead of removing their TTL.
+func WithMaxIdleTime(maxIdle time.Duration) Option {
+	return func(o *options) {
+		o.maxIdle = maxIdle
+	}
+}
@@ -2,8 +2,12 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/razzie/razcache"
@@ -31,83 +35,263 @@ func NewRedisCacheFromClient(client redis.Cmdable) razcache.ExtendedCache {
 }
 
 func (c *redisCache) Set(key, value string, ttl time.Duration) error {
-	err := c.client.Set(context.Background(), key, value, ttl).Err()
-	return translateRedisError(err)
+	return c.set(key, value, ttl)
+}
+
+func (c *redisCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return c.set(key, value, ttl)
+}
+
+// set takes a string or a byte slice, which go-redis writes without conversion
+func (c *redisCache) set(key string, value any, ttl time.Duration) error {
+	if ttl < 0 {
+		return c.Del(key)
+	}
+	err := c.client.Set(context.Background(), key, value, roundTTL(ttl)).Err()
+	return translateRedisError("Set", key, err)
 }
 
 func (c *redisCache) Get(key string) (string, error) {
 	result, err := c.client.Get(context.Background(), key).Result()
-	return result, translateRedisError(err)
+	return result, translateRedisError("Get", key, err)
+}
+
+func (c *redisCache) GetBytes(key string) ([]byte, error) {
+	result, err := c.client.Get(context.Background(), key).Bytes()
+	return result, translateRedisError("GetBytes", key, err)
+}
+
+func (c *redisCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	result, err := c.client.Get(context.Background(), key).Result()
+	if err != nil {
+		return dst, translateRedisError("AppendBytes", key, err)
+	}
+	return append(dst, result...), nil
+}
+
+// GetEx sends GETEX, or GETDEL for a negative TTL
+func (c *redisCache) GetEx(key string, ttl time.Duration) (string, error) {
+	var result string
+	var err error
+	if ttl < 0 {
+		result, err = c.client.GetDel(context.Background(), key).Result()
+	} else {
+		result, err = c.client.GetEx(context.Background(), key, roundTTL(ttl)).Result()
+	}
+	return result, translateRedisError("GetEx", key, err)
 }
 
 func (c *redisCache) Del(key string) error {
 	err := c.client.Del(context.Background(), key).Err()
-	return translateRedisError(err)
+	return translateRedisError("Del", key, err)
 }
 
+// GetTTL maps the -1 and -2 replies of PTTL to NoExpiry and ErrNotFound
 func (c *redisCache) GetTTL(key string) (time.Duration, error) {
-	result, err := c.client.TTL(context.Background(), key).Result()
-	return result, translateRedisError(err)
+	result, err := c.client.PTTL(context.Background(), key).Result()
+	if err != nil {
+		return 0, translateRedisError("GetTTL", key, err)
+	}
+	switch {
+	case result == -1:
+		return razcache.NoExpiry, nil
+	case result <= 0: // -2 or about to expire
+		return 0, razcache.ErrNotFound
+	default:
+		return result, nil
+	}
 }
 
+// SetTTL persists the key for a TTL of 0 and deletes it for a negative TTL,
+// while Redis would delete it in both cases
 func (c *redisCache) SetTTL(key string, ttl time.Duration) error {
-	err := c.client.Expire(context.Background(), key, ttl).Err()
-	return translateRedisError(err)
+	ctx := context.Background()
+	var ok bool
+	var err error
+	switch {
+	case ttl < 0:
+		var n int64
+		n, err = c.client.Del(ctx, key).Result()
+		ok = n > 0
+	case ttl == 0:
+		// PERSIST doesn't tell missing keys and keys without TTL apart
+		if ok, err = c.client.Persist(ctx, key).Result(); err == nil && !ok {
+			var n int64
+			n, err = c.client.Exists(ctx, key).Result()
+			ok = n > 0
+		}
+	default:
+		ok, err = c.client.PExpire(ctx, key, roundTTL(ttl)).Result()
+	}
+	if err != nil {
+		return translateRedisError("SetTTL", key, err)
+	}
+	if !ok {
+		return razcache.ErrNotFound
+	}
+	return nil
+}
+
+func (c *redisCache) Expire(key string, ttl time.Duration, cond razcache.ExpireCondition) (bool, error) {
+	return c.expire("PEXPIRE", key, roundTTL(ttl).Milliseconds(), cond)
+}
+
+func (c *redisCache) ExpireAt(key string, deadline time.Time, cond razcache.ExpireCondition) (bool, error) {
+	return c.expire("PEXPIREAT", key, deadline.UnixMilli(), cond)
+}
+
+// expire sends a PEXPIRE or PEXPIREAT command, followed by EXISTS in the same pipeline
+// to tell missing keys apart from unmet conditions
+func (c *redisCache) expire(cmd, key string, ms int64, cond razcache.ExpireCondition) (bool, error) {
+	args := []any{cmd, key, ms}
+	if cond != razcache.ExpireAlways {
+		args = append(args, cond.String())
+	}
+	ctx := context.Background()
+	pipe := c.client.Pipeline()
+	result := pipe.Do(ctx, args...)
+	exists := pipe.Exists(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, translateRedisError("Expire", key, err)
+	}
+	if n, _ := result.Int64(); n == 1 {
+		return true, nil
+	}
+	if exists.Val() == 0 {
+		return false, razcache.ErrNotFound
+	}
+	return false, nil
+}
+
+func (c *redisCache) Persist(key string) error {
+	return c.SetTTL(key, 0)
+}
+
+func (c *redisCache) SetKeepTTL(key, value string) error {
+	err := c.client.Set(context.Background(), key, value, redis.KeepTTL).Err()
+	return translateRedisError("SetKeepTTL", key, err)
 }
 
+// roundTTL rounds positive TTLs up to the millisecond precision of Redis
+func roundTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < time.Millisecond {
+		return time.Millisecond
+	}
+	return ttl
+}
+
+// LPush keeps the order of values like the other backends,
+// while Redis would push them one by one in reverse order
 func (c *redisCache) LPush(key string, values ...string) error {
-	err := c.client.LPush(context.Background(), key, stringToAnySlice(values)...).Err()
-	return translateRedisError(err)
+	if len(values) == 0 {
+		return c.checkList("LPush", key)
+	}
+	args := stringToAnySlice(values)
+	slices.Reverse(args)
+	err := c.client.LPush(context.Background(), key, args...).Err()
+	return translateRedisError("LPush", key, err)
 }
 
 func (c *redisCache) RPush(key string, values ...string) error {
+	if len(values) == 0 {
+		return c.checkList("RPush", key)
+	}
 	err := c.client.RPush(context.Background(), key, stringToAnySlice(values)...).Err()
-	return translateRedisError(err)
+	return translateRedisError("RPush", key, err)
 }
 
 func (c *redisCache) LPop(key string, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, c.checkList("LPop", key)
+	}
 	result, err := c.client.LPopCount(context.Background(), key, count).Result()
-	return result, translateRedisError(err)
+	return result, translatePopError("LPop", key, err)
 }
 
+// RPop returns the values in list order like the other backends,
+// while Redis returns them in the order they were popped
 func (c *redisCache) RPop(key string, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, c.checkList("RPop", key)
+	}
 	result, err := c.client.RPopCount(context.Background(), key, count).Result()
-	return result, translateRedisError(err)
+	slices.Reverse(result)
+	return result, translatePopError("RPop", key, err)
 }
 
 func (c *redisCache) LLen(key string) (int, error) {
 	result, err := c.client.LLen(context.Background(), key).Result()
-	return int(result), translateRedisError(err)
+	return int(result), translateRedisError("LLen", key, err)
 }
 
 func (c *redisCache) LRange(key string, start, stop int) ([]string, error) {
 	result, err := c.client.LRange(context.Background(), key, int64(start), int64(stop)).Result()
-	return result, translateRedisError(err)
+	if len(result) == 0 {
+		result = nil
+	}
+	return result, translateRedisError("LRange", key, err)
 }
 
 func (c *redisCache) SAdd(key string, values ...string) error {
+	if len(values) == 0 {
+		return c.checkSet("SAdd", key)
+	}
 	err := c.client.SAdd(context.Background(), key, stringToAnySlice(values)...).Err()
-	return translateRedisError(err)
+	return translateRedisError("SAdd", key, err)
 }
 
 func (c *redisCache) SRem(key string, values ...string) error {
+	if len(values) == 0 {
+		return c.checkSet("SRem", key)
+	}
 	err := c.client.SRem(context.Background(), key, stringToAnySlice(values)...).Err()
-	return translateRedisError(err)
+	return translateRedisError("SRem", key, err)
+}
+
+func (c *redisCache) SAddCount(key string, values ...string) (int, error) {
+	if len(values) == 0 {
+		return 0, c.checkSet("SAddCount", key)
+	}
+	result, err := c.client.SAdd(context.Background(), key, stringToAnySlice(values)...).Result()
+	return int(result), translateRedisError("SAddCount", key, err)
+}
+
+func (c *redisCache) SRemCount(key string, values ...string) (int, error) {
+	if len(values) == 0 {
+		return 0, c.checkSet("SRemCount", key)
+	}
+	result, err := c.client.SRem(context.Background(), key, stringToAnySlice(values)...).Result()
+	return int(result), translateRedisError("SRemCount", key, err)
 }
 
 func (c *redisCache) SHas(key, value string) (bool, error) {
 	result, err := c.client.SIsMember(context.Background(), key, value).Result()
-	return result, translateRedisError(err)
+	return result, translateRedisError("SHas", key, err)
 }
 
 func (c *redisCache) SLen(key string) (int, error) {
 	result, err := c.client.SCard(context.Background(), key).Result()
-	return int(result), translateRedisError(err)
+	return int(result), translateRedisError("SLen", key, err)
+}
+
+func (c *redisCache) SMembers(key string) ([]string, error) {
+	result, err := c.client.SMembers(context.Background(), key).Result()
+	return result, translateRedisError("SMembers", key, err)
 }
 
 func (c *redisCache) Incr(key string, increment int64) (int64, error) {
 	result, err := c.client.IncrBy(context.Background(), key, increment).Result()
-	return result, translateRedisError(err)
+	return result, translateRedisError("Incr", key, err)
+}
+
+func (c *redisCache) Scan(prefix string, fn func(key string) bool) error {
+	iter := c.client.Scan(context.Background(), 0, escapePattern(prefix)+"*", 0).Iterator()
+	for iter.Next(context.Background()) {
+		if !fn(iter.Val()) {
+			return nil
+		}
+	}
+	return translateRedisError("Scan", prefix, iter.Err())
 }
 
 func (c *redisCache) SubCache(prefix string) razcache.Cache {
@@ -120,22 +304,95 @@ func (c *redisCache) SubExtendedCache(prefix string) razcache.ExtendedCache {
 
 func (c *redisCache) Close() error {
 	if client, ok := c.client.(io.Closer); ok {
-		return client.Close()
+		// go-redis returns ErrClosed if the client is already closed
+		if err := client.Close(); err != redis.ErrClosed {
+			return err
+		}
 	}
 	return nil
 }
 
-func translateRedisError(err error) error {
+// checkList returns ErrWrongType if the key holds something else than a list,
+// as Redis doesn't accept pushing zero values or popping a non-positive count
+func (c *redisCache) checkList(op, key string) error {
+	return translateRedisError(op, key, c.client.LLen(context.Background(), key).Err())
+}
+
+// checkSet returns ErrWrongType if the key holds something else than a set,
+// as Redis doesn't accept adding or removing zero members
+func (c *redisCache) checkSet(op, key string) error {
+	return translateRedisError(op, key, c.client.SCard(context.Background(), key).Err())
+}
+
+// translatePopError treats popping from an empty or missing list as an empty result
+func translatePopError(op, key string, err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+	return translateRedisError(op, key, err)
+}
+
+// translateRedisError returns ErrNotFound and ErrWrongType as they are,
+// and wraps other errors in a razcache.Error
+func translateRedisError(op, key string, err error) error {
 	switch {
 	case err == nil:
 		return nil
 	case err == redis.Nil:
 		return razcache.ErrNotFound
-	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"),
+		strings.HasPrefix(err.Error(), "ERR value is not an integer"):
 		return razcache.ErrWrongType
 	default:
-		return err
+		return &razcache.Error{Op: op, Key: key, Backend: "redis", Kind: redisErrorKind(err), Err: err}
+	}
+}
+
+// redisErrorKind classifies errors of go-redis and Redis, or returns nil if it can't
+func redisErrorKind(err error) error {
+	var netErr net.Error
+	switch {
+	case err == redis.ErrClosed:
+		return razcache.ErrCacheClosed
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return razcache.ErrTimeout
+	case errors.Is(err, io.EOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, net.ErrClosed):
+		return razcache.ErrUnavailable
+	}
+	msg := err.Error()
+	for _, prefix := range []string{"LOADING", "BUSY ", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN", "READONLY", "OOM"} {
+		if strings.HasPrefix(msg, prefix) {
+			return razcache.ErrUnavailable
+		}
+	}
+	switch {
+	case strings.HasPrefix(msg, "BUSYKEY"), strings.HasPrefix(msg, "EXECABORT"):
+		return razcache.ErrConflict
+	case strings.Contains(msg, "exceeds maximum allowed size"):
+		return razcache.ErrValueTooLarge
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return razcache.ErrUnavailable
+	}
+	return nil
+}
+
+// escapePattern escapes the glob special characters of a SCAN pattern
+func escapePattern(pattern string) string {
+	var sb strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
 	}
+	return sb.String()
 }
 
 func stringToAnySlice(strings []string) []any {
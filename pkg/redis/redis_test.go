@@ -0,0 +1,136 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/razzie/razcache"
+	. "github.com/razzie/razcache/pkg/redis"
+	"github.com/razzie/razcache/pkg/server/servertest"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func newTestCache(t *testing.T) razcache.ExtendedCache {
+	srv := servertest.NewServer()
+	t.Cleanup(srv.Close)
+	cache, err := NewRedisCache(srv.URL())
+	require.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestRedisBasic(t *testing.T) {
+	testutil.TestBasic(t, newTestCache(t))
+}
+
+func TestRedisTTL(t *testing.T) {
+	testutil.TestTTL(t, newTestCache(t), time.Millisecond*50)
+}
+
+func TestRedisLists(t *testing.T) {
+	testutil.TestLists(t, newTestCache(t))
+}
+
+func TestRedisSets(t *testing.T) {
+	testutil.TestSets(t, newTestCache(t))
+}
+
+func TestRedisIncr(t *testing.T) {
+	testutil.TestIncr(t, newTestCache(t))
+}
+
+func TestRedisListOrder(t *testing.T) {
+	cache := newTestCache(t)
+
+	// LPush and RPop should behave like in other backends
+	assert.NoError(t, cache.RPush("list", "3", "4", "5"))
+	assert.NoError(t, cache.LPush("list", "1", "2"))
+	values, err := cache.LRange("list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, values)
+	values, err = cache.RPop("list", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4", "5"}, values)
+}
+
+func TestRedisErrors(t *testing.T) {
+	cache := newTestCache(t)
+
+	_, err := cache.Get("missing")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// WRONGTYPE and non-integer errors should both be translated
+	assert.NoError(t, cache.SAdd("set", "a"))
+	_, err = cache.Get("set")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = cache.Incr("set", 1)
+	assert.Equal(t, razcache.ErrWrongType, err)
+	assert.NoError(t, cache.Set("str", "a", 0))
+	_, err = cache.Incr("str", 1)
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func TestRedisErrorContext(t *testing.T) {
+	srv := servertest.NewServer()
+	cache, err := NewRedisCache(srv.URL())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	// failures carry the operation and the key with the prefixes of sub caches
+	srv.Close()
+	_, err = cache.SubCache("sub:").Get("key")
+	assert.ErrorIs(t, err, razcache.ErrUnavailable)
+	var cacheErr *razcache.Error
+	require.ErrorAs(t, err, &cacheErr)
+	assert.Equal(t, "redis", cacheErr.Backend)
+	assert.Equal(t, "Get", cacheErr.Op)
+	assert.Equal(t, "sub:key", cacheErr.Key)
+
+	assert.NoError(t, cache.Close())
+	err = cache.LPush("list", "a")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	require.ErrorAs(t, err, &cacheErr)
+	assert.Equal(t, "LPush", cacheErr.Op)
+	assert.Equal(t, "list", cacheErr.Key)
+}
+
+func TestRedisScan(t *testing.T) {
+	cache := newTestCache(t)
+
+	assert.NoError(t, cache.Set("a:1", "1", 0))
+	assert.NoError(t, cache.Set("a:2", "2", 0))
+	assert.NoError(t, cache.Set("a*", "3", 0))
+	assert.NoError(t, cache.Set("b:1", "1", 0))
+
+	keys, err := razcache.ScanKeys(cache, "a:")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a:1", "a:2"}, keys)
+
+	// glob characters in the prefix should be matched literally
+	keys, err = razcache.ScanKeys(cache, "a*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a*"}, keys)
+}
+
+func TestRedisConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) razcache.Cache {
+		srv := servertest.NewServer()
+		t.Cleanup(srv.Close)
+		cache, err := NewRedisCache(srv.URL())
+		require.NoError(t, err)
+		return cache
+	}, testutil.WithTTLGranularity(time.Second)) // TTL has a resolution of seconds
+}
+
+func BenchmarkRedis(b *testing.B) {
+	testutil.RunBenchmarks(b, func(b *testing.B) razcache.Cache {
+		srv := servertest.NewServer()
+		b.Cleanup(srv.Close)
+		cache, err := NewRedisCache(srv.URL())
+		require.NoError(b, err)
+		return cache
+	})
+}
@@ -0,0 +1,655 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+const (
+	errSyntax     = "ERR syntax error"
+	errNotInteger = "ERR value is not an integer or out of range"
+	errWrongType  = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errInvalidTTL = "ERR invalid expire time in '%s' command"
+)
+
+type command struct {
+	// number of arguments including the command name,
+	// or the negated minimum if the command takes a variable number of them
+	arity   int
+	handler func(s *Server, w *respWriter, args []string)
+}
+
+var commands = map[string]command{
+	"PING":      {-1, cmdPing},
+	"ECHO":      {2, cmdEcho},
+	"HELLO":     {-1, cmdHello},
+	"SELECT":    {2, cmdSelect},
+	"CLIENT":    {-2, cmdClient},
+	"COMMAND":   {-1, cmdCommand},
+	"GET":       {2, cmdGet},
+	"GETEX":     {-2, cmdGetEx},
+	"GETDEL":    {2, cmdGetDel},
+	"SET":       {-3, cmdSet},
+	"DEL":       {-2, cmdDel},
+	"EXISTS":    {-2, cmdExists},
+	"SCAN":      {-2, cmdScan},
+	"TTL":       {2, cmdTTL},
+	"PTTL":      {2, cmdPTTL},
+	"EXPIRE":    {-3, cmdExpire},
+	"PEXPIRE":   {-3, cmdPExpire},
+	"EXPIREAT":  {-3, cmdExpireAt},
+	"PEXPIREAT": {-3, cmdPExpireAt},
+	"PERSIST":   {2, cmdPersist},
+	"LPUSH":     {-3, cmdLPush},
+	"RPUSH":     {-3, cmdRPush},
+	"LPOP":      {-2, cmdLPop},
+	"RPOP":      {-2, cmdRPop},
+	"LLEN":      {2, cmdLLen},
+	"LRANGE":    {4, cmdLRange},
+	"SADD":      {-3, cmdSAdd},
+	"SREM":      {-3, cmdSRem},
+	"SISMEMBER": {3, cmdSIsMember},
+	"SCARD":     {2, cmdSCard},
+	"SMEMBERS":  {2, cmdSMembers},
+	"INCR":      {2, cmdIncr},
+	"DECR":      {2, cmdDecr},
+	"INCRBY":    {3, cmdIncrBy},
+	"DECRBY":    {3, cmdDecrBy},
+}
+
+// execute runs the command and writes its reply, and returns true if the
+// connection should be closed
+func (s *Server) execute(w *respWriter, args []string) (quit bool) {
+	name := strings.ToUpper(args[0])
+	if name == "QUIT" {
+		w.writeSimple("OK")
+		return true
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		w.writeError("ERR unknown command '" + args[0] + "'")
+		return false
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		w.writeError("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+		return false
+	}
+	cmd.handler(s, w, args)
+	return false
+}
+
+// writeCacheError writes the error reply, and returns false if there was no error
+func writeCacheError(w *respWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, razcache.ErrWrongType):
+		w.writeError(errWrongType)
+	default:
+		w.writeError("ERR " + err.Error())
+	}
+	return true
+}
+
+func cmdPing(s *Server, w *respWriter, args []string) {
+	switch len(args) {
+	case 1:
+		w.writeSimple("PONG")
+	case 2:
+		w.writeBulk(args[1])
+	default:
+		w.writeError("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func cmdEcho(s *Server, w *respWriter, args []string) {
+	w.writeBulk(args[1])
+}
+
+func cmdHello(s *Server, w *respWriter, args []string) {
+	proto := w.proto
+	if len(args) > 1 {
+		var err error
+		proto, err = strconv.Atoi(args[1])
+		if err != nil || proto < 2 || proto > 3 {
+			w.writeError("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+	w.proto = proto
+	w.writeMapLen(7)
+	w.writeBulk("server")
+	w.writeBulk("razcache")
+	w.writeBulk("version")
+	w.writeBulk("7.0.0")
+	w.writeBulk("proto")
+	w.writeInt(int64(proto))
+	w.writeBulk("id")
+	w.writeInt(0)
+	w.writeBulk("mode")
+	w.writeBulk("standalone")
+	w.writeBulk("role")
+	w.writeBulk("master")
+	w.writeBulk("modules")
+	w.writeArrayLen(0)
+}
+
+func cmdSelect(s *Server, w *respWriter, args []string) {
+	if args[1] != "0" {
+		w.writeError("ERR DB index is out of range")
+		return
+	}
+	w.writeSimple("OK")
+}
+
+func cmdClient(s *Server, w *respWriter, args []string) {
+	switch strings.ToUpper(args[1]) {
+	case "SETNAME", "SETINFO":
+		w.writeSimple("OK")
+	case "ID":
+		w.writeInt(0)
+	default:
+		w.writeError("ERR unknown subcommand '" + args[1] + "'")
+	}
+}
+
+func cmdCommand(s *Server, w *respWriter, args []string) {
+	w.writeArrayLen(0)
+}
+
+func cmdGet(s *Server, w *respWriter, args []string) {
+	value, err := s.cache.Get(args[1])
+	writeValue(w, value, err)
+}
+
+func writeValue(w *respWriter, value string, err error) {
+	if errors.Is(err, razcache.ErrNotFound) {
+		w.writeNull()
+		return
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeBulk(value)
+}
+
+// parseTTL parses the positive number of seconds (EX, EXAT) or milliseconds (PX, PXAT)
+// of the option, and writes an error reply if it's invalid or the duration would overflow
+func parseTTL(w *respWriter, cmd, option, arg string) (time.Duration, bool) {
+	unit := time.Second
+	if option[0] == 'P' {
+		unit = time.Millisecond
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n > math.MaxInt64/int64(unit) {
+		w.writeError(errNotInteger)
+		return 0, false
+	}
+	if n <= 0 {
+		w.writeError(fmt.Sprintf(errInvalidTTL, cmd))
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// cmdGetEx deletes the key for deadlines in the past, like redis does
+func cmdGetEx(s *Server, w *respWriter, args []string) {
+	if len(args) == 2 {
+		cmdGet(s, w, args)
+		return
+	}
+	var ttl time.Duration
+	switch option := strings.ToUpper(args[2]); option {
+	case "PERSIST":
+		if len(args) != 3 {
+			w.writeError(errSyntax)
+			return
+		}
+	case "EX", "PX", "EXAT", "PXAT":
+		if len(args) != 4 {
+			w.writeError(errSyntax)
+			return
+		}
+		var ok bool
+		if ttl, ok = parseTTL(w, "getex", option, args[3]); !ok {
+			return
+		}
+		if strings.HasSuffix(option, "AT") {
+			if ttl = time.Until(time.Unix(0, 0).Add(ttl)); ttl <= 0 {
+				ttl = -1
+			}
+		}
+	default:
+		w.writeError(errSyntax)
+		return
+	}
+	value, err := razcache.GetEx(s.cache, args[1], ttl)
+	writeValue(w, value, err)
+}
+
+func cmdGetDel(s *Server, w *respWriter, args []string) {
+	value, err := razcache.GetEx(s.cache, args[1], -1)
+	writeValue(w, value, err)
+}
+
+func cmdSet(s *Server, w *respWriter, args []string) {
+	var ttl time.Duration
+	var keepTTL bool
+	for i := 3; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); option {
+		case "KEEPTTL":
+			if ttl != 0 {
+				w.writeError(errSyntax)
+				return
+			}
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 >= len(args) || ttl != 0 || keepTTL {
+				w.writeError(errSyntax)
+				return
+			}
+			i++
+			var ok bool
+			if ttl, ok = parseTTL(w, "set", option, args[i]); !ok {
+				return
+			}
+		default:
+			w.writeError(errSyntax)
+			return
+		}
+	}
+	var err error
+	if keepTTL {
+		err = razcache.SetKeepTTL(s.cache, args[1], args[2])
+	} else {
+		err = s.cache.Set(args[1], args[2], ttl)
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeSimple("OK")
+}
+
+// exists reports whether the key exists, regardless of its type
+func (s *Server) exists(key string) (bool, error) {
+	_, err := s.cache.GetTTL(key)
+	if errors.Is(err, razcache.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func cmdDel(s *Server, w *respWriter, args []string) {
+	var deleted int64
+	for _, key := range args[1:] {
+		exists, err := s.exists(key)
+		if writeCacheError(w, err) {
+			return
+		}
+		if !exists {
+			continue
+		}
+		if writeCacheError(w, s.cache.Del(key)) {
+			return
+		}
+		deleted++
+	}
+	w.writeInt(deleted)
+}
+
+func cmdExists(s *Server, w *respWriter, args []string) {
+	var count int64
+	for _, key := range args[1:] {
+		exists, err := s.exists(key)
+		if writeCacheError(w, err) {
+			return
+		}
+		if exists {
+			count++
+		}
+	}
+	w.writeInt(count)
+}
+
+// cmdScan returns all matching keys in one batch, so the cursor is always 0
+func cmdScan(s *Server, w *respWriter, args []string) {
+	if _, err := strconv.ParseUint(args[1], 10, 64); err != nil {
+		w.writeError("ERR invalid cursor")
+		return
+	}
+	pattern := "*"
+	for i := 2; i < len(args); i++ {
+		if i+1 >= len(args) {
+			w.writeError(errSyntax)
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if _, err := strconv.Atoi(args[i+1]); err != nil {
+				w.writeError(errNotInteger)
+				return
+			}
+		default:
+			w.writeError(errSyntax)
+			return
+		}
+		i++
+	}
+	var keys []string
+	if args[1] == "0" {
+		err := razcache.Scan(s.cache, globPrefix(pattern), func(key string) bool {
+			if matchGlob(pattern, key) {
+				keys = append(keys, key)
+			}
+			return true
+		})
+		if writeCacheError(w, err) {
+			return
+		}
+	}
+	w.writeArrayLen(2)
+	w.writeBulk("0")
+	w.writeStrings(keys)
+}
+
+func (s *Server) ttl(w *respWriter, key string, unit time.Duration) {
+	ttl, err := s.cache.GetTTL(key)
+	if errors.Is(err, razcache.ErrNotFound) {
+		w.writeInt(-2)
+		return
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	if ttl == razcache.NoExpiry {
+		w.writeInt(-1)
+		return
+	}
+	// round up like redis does, so keys about to expire don't report 0
+	w.writeInt(int64((ttl + unit - 1) / unit))
+}
+
+func cmdTTL(s *Server, w *respWriter, args []string) {
+	s.ttl(w, args[1], time.Second)
+}
+
+func cmdPTTL(s *Server, w *respWriter, args []string) {
+	s.ttl(w, args[1], time.Millisecond)
+}
+
+// expire handles the EXPIRE family of commands. A deadline is absolute if at is true.
+// Conditions require a cache that implements razcache.Expirer.
+func (s *Server) expire(w *respWriter, args []string, unit time.Duration, at bool) {
+	key := args[1]
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		w.writeError(errNotInteger)
+		return
+	}
+	if len(args) > 4 {
+		w.writeError(errSyntax)
+		return
+	}
+	cond := razcache.ExpireAlways
+	if len(args) == 4 {
+		var ok bool
+		if cond, ok = expireConditions[strings.ToUpper(args[3])]; !ok {
+			w.writeError("ERR Unsupported option " + args[3])
+			return
+		}
+	}
+
+	var ok bool
+	switch {
+	case at:
+		ok, err = razcache.ExpireAt(s.cache, key, time.Unix(0, 0).Add(time.Duration(n)*unit), cond)
+	case cond != razcache.ExpireAlways:
+		ok, err = razcache.Expire(s.cache, key, time.Duration(n)*unit, cond)
+	default:
+		ok, err = s.expireAlways(key, time.Duration(n)*unit)
+	}
+	if errors.Is(err, razcache.ErrNotFound) {
+		err = nil
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	if ok {
+		w.writeInt(1)
+	} else {
+		w.writeInt(0)
+	}
+}
+
+var expireConditions = map[string]razcache.ExpireCondition{
+	"NX": razcache.ExpireNX,
+	"XX": razcache.ExpireXX,
+	"GT": razcache.ExpireGT,
+	"LT": razcache.ExpireLT,
+}
+
+// expireAlways works with any cache, deleting the key for non-positive TTLs like redis does
+func (s *Server) expireAlways(key string, ttl time.Duration) (bool, error) {
+	exists, err := s.exists(key)
+	if err != nil || !exists {
+		return false, err
+	}
+	if ttl <= 0 {
+		err = s.cache.Del(key)
+	} else {
+		err = s.cache.SetTTL(key, ttl)
+	}
+	return err == nil, err
+}
+
+func cmdExpire(s *Server, w *respWriter, args []string) {
+	s.expire(w, args, time.Second, false)
+}
+
+func cmdPExpire(s *Server, w *respWriter, args []string) {
+	s.expire(w, args, time.Millisecond, false)
+}
+
+func cmdExpireAt(s *Server, w *respWriter, args []string) {
+	s.expire(w, args, time.Second, true)
+}
+
+func cmdPExpireAt(s *Server, w *respWriter, args []string) {
+	s.expire(w, args, time.Millisecond, true)
+}
+
+func cmdPersist(s *Server, w *respWriter, args []string) {
+	ttl, err := s.cache.GetTTL(args[1])
+	if errors.Is(err, razcache.ErrNotFound) || (err == nil && ttl == razcache.NoExpiry) {
+		w.writeInt(0)
+		return
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	if writeCacheError(w, s.cache.SetTTL(args[1], 0)) {
+		return
+	}
+	w.writeInt(1)
+}
+
+func (s *Server) push(w *respWriter, args []string, push func(key string, values ...string) error) {
+	if writeCacheError(w, push(args[1], args[2:]...)) {
+		return
+	}
+	length, err := s.cache.LLen(args[1])
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(int64(length))
+}
+
+// LPUSH inserts the values one after the other, so they end up reversed
+func cmdLPush(s *Server, w *respWriter, args []string) {
+	slices.Reverse(args[2:])
+	s.push(w, args, s.cache.LPush)
+}
+
+func cmdRPush(s *Server, w *respWriter, args []string) {
+	s.push(w, args, s.cache.RPush)
+}
+
+func (s *Server) pop(w *respWriter, args []string, pop func(key string, count int) ([]string, error)) {
+	if len(args) > 3 {
+		w.writeError(errSyntax)
+		return
+	}
+	count := 1
+	if len(args) == 3 {
+		var err error
+		count, err = strconv.Atoi(args[2])
+		if err != nil || count < 0 {
+			w.writeError("ERR value is out of range, must be positive")
+			return
+		}
+	}
+	values, err := pop(args[1], count)
+	if writeCacheError(w, err) {
+		return
+	}
+	switch {
+	case len(args) == 2 && len(values) == 0:
+		w.writeNull()
+	case len(args) == 2:
+		w.writeBulk(values[0])
+	case len(values) == 0:
+		w.writeNullArray()
+	default:
+		w.writeStrings(values)
+	}
+}
+
+func cmdLPop(s *Server, w *respWriter, args []string) {
+	s.pop(w, args, s.cache.LPop)
+}
+
+// RPOP returns the values in the order they were popped
+func cmdRPop(s *Server, w *respWriter, args []string) {
+	s.pop(w, args, func(key string, count int) ([]string, error) {
+		values, err := s.cache.RPop(key, count)
+		values = slices.Clone(values)
+		slices.Reverse(values)
+		return values, err
+	})
+}
+
+func cmdLLen(s *Server, w *respWriter, args []string) {
+	length, err := s.cache.LLen(args[1])
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(int64(length))
+}
+
+func cmdLRange(s *Server, w *respWriter, args []string) {
+	start, err1 := strconv.Atoi(args[2])
+	stop, err2 := strconv.Atoi(args[3])
+	if err1 != nil || err2 != nil {
+		w.writeError(errNotInteger)
+		return
+	}
+	values, err := s.cache.LRange(args[1], start, stop)
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeStrings(values)
+}
+
+func cmdSAdd(s *Server, w *respWriter, args []string) {
+	added, err := razcache.SAddCount(s.cache, args[1], args[2:]...)
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(int64(added))
+}
+
+func cmdSRem(s *Server, w *respWriter, args []string) {
+	removed, err := razcache.SRemCount(s.cache, args[1], args[2:]...)
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(int64(removed))
+}
+
+func cmdSIsMember(s *Server, w *respWriter, args []string) {
+	found, err := s.cache.SHas(args[1], args[2])
+	if writeCacheError(w, err) {
+		return
+	}
+	if found {
+		w.writeInt(1)
+	} else {
+		w.writeInt(0)
+	}
+}
+
+func cmdSCard(s *Server, w *respWriter, args []string) {
+	length, err := s.cache.SLen(args[1])
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(int64(length))
+}
+
+func cmdSMembers(s *Server, w *respWriter, args []string) {
+	members, err := razcache.SMembers(s.cache, args[1])
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeStrings(members)
+}
+
+func (s *Server) incr(w *respWriter, key string, increment int64) {
+	value, err := s.cache.Incr(key, increment)
+	if errors.Is(err, razcache.ErrWrongType) {
+		// like Redis, distinguish non-integer strings from other types
+		if _, getErr := s.cache.Get(key); getErr == nil {
+			w.writeError(errNotInteger)
+			return
+		}
+	}
+	if writeCacheError(w, err) {
+		return
+	}
+	w.writeInt(value)
+}
+
+func cmdIncr(s *Server, w *respWriter, args []string) {
+	s.incr(w, args[1], 1)
+}
+
+func cmdDecr(s *Server, w *respWriter, args []string) {
+	s.incr(w, args[1], -1)
+}
+
+func cmdIncrBy(s *Server, w *respWriter, args []string) {
+	increment, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		w.writeError(errNotInteger)
+		return
+	}
+	s.incr(w, args[1], increment)
+}
+
+func cmdDecrBy(s *Server, w *respWriter, args []string) {
+	decrement, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || decrement == math.MinInt64 {
+		w.writeError(errNotInteger)
+		return
+	}
+	s.incr(w, args[1], -decrement)
+}
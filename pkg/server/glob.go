@@ -0,0 +1,109 @@
+package server
+
+import (
+	"strings"
+)
+
+// globPrefix returns the literal prefix of a glob pattern
+func globPrefix(pattern string) string {
+	var prefix strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*', '?', '[':
+			return prefix.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			prefix.WriteByte(c)
+		default:
+			prefix.WriteByte(c)
+		}
+	}
+	return prefix.String()
+}
+
+// matchGlob matches the string against a Redis style glob pattern
+// supporting *, ?, [abc], [^abc], [a-z] and backslash escapes.
+// On a mismatch only the last * takes one more character, as earlier ones
+// never need to, so matching takes O(len(pattern)*len(s)) steps at most.
+func matchGlob(pattern, s string) bool {
+	var starPattern, starS string
+	star := false
+	for len(pattern) > 0 || len(s) > 0 {
+		if len(pattern) > 0 {
+			switch pattern[0] {
+			case '*':
+				for len(pattern) > 0 && pattern[0] == '*' {
+					pattern = pattern[1:]
+				}
+				if len(pattern) == 0 {
+					return true
+				}
+				starPattern, starS, star = pattern, s, true
+				continue
+			case '?':
+				if len(s) > 0 {
+					pattern, s = pattern[1:], s[1:]
+					continue
+				}
+			case '[':
+				if len(s) > 0 {
+					if rest, ok := matchClass(pattern[1:], s[0]); ok {
+						pattern, s = rest, s[1:]
+						continue
+					}
+				}
+			default:
+				c, rest := pattern[0], pattern[1:]
+				if c == '\\' && len(rest) > 0 {
+					c, rest = rest[0], rest[1:]
+				}
+				if len(s) > 0 && s[0] == c {
+					pattern, s = rest, s[1:]
+					continue
+				}
+			}
+		}
+		if !star || len(starS) == 0 {
+			return false
+		}
+		starS = starS[1:]
+		pattern, s = starPattern, starS
+	}
+	return true
+}
+
+// matchClass matches c against the character class at the beginning of the
+// pattern (after '['), and returns the rest of the pattern after ']'
+func matchClass(pattern string, c byte) (rest string, ok bool) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	var match bool
+	for len(pattern) > 0 && pattern[0] != ']' {
+		lo := pattern[0]
+		if lo == '\\' && len(pattern) > 1 {
+			pattern = pattern[1:]
+			lo = pattern[0]
+		}
+		pattern = pattern[1:]
+		hi := lo
+		if len(pattern) > 1 && pattern[0] == '-' && pattern[1] != ']' {
+			hi = pattern[1]
+			pattern = pattern[2:]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= c && c <= hi {
+			match = true
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:] // skip ']'
+	}
+	return pattern, match != negate
+}
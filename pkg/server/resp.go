@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxBulkLen   = 512 * 1024 * 1024
+	maxArrayLen  = 1024 * 1024
+	maxInlineLen = 64 * 1024 // like redis, for inline commands and the headers of RESP ones
+
+	// lengths in headers are only claims of the client, so buffers are grown
+	// as the data arrives instead of being allocated up front
+	preallocBulkLen  = 16 * 1024
+	preallocArrayLen = 1024
+)
+
+var errProtocol = errors.New("protocol error")
+
+type respReader struct {
+	r *bufio.Reader
+}
+
+// readCommand reads a command either in RESP array or inline format
+func (r *respReader) readCommand() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArrayLen {
+		return nil, errProtocol
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	args := make([]string, 0, min(n, preallocArrayLen))
+	for i := 0; i < n; i++ {
+		arg, err := r.readBulk()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (r *respReader) readBulk() (string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return "", errProtocol
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxBulkLen {
+		return "", errProtocol
+	}
+	var sb strings.Builder
+	sb.Grow(min(n, preallocBulkLen))
+	if _, err := io.CopyN(&sb, r.r, int64(n)); err != nil {
+		return "", err
+	}
+	var crlf [2]byte
+	if _, err := io.ReadFull(r.r, crlf[:]); err != nil {
+		return "", err
+	}
+	if crlf != [2]byte{'\r', '\n'} {
+		return "", errProtocol
+	}
+	return sb.String(), nil
+}
+
+func (r *respReader) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxInlineLen {
+			return "", errProtocol
+		}
+		line = append(line, chunk...)
+		if err == nil {
+			break
+		}
+		if err != bufio.ErrBufferFull {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// respWriter writes replies in RESP2 or RESP3 format depending on proto
+type respWriter struct {
+	w     *bufio.Writer
+	proto int
+}
+
+func (w *respWriter) writeLine(prefix byte, s string) {
+	w.w.WriteByte(prefix)
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *respWriter) writeSimple(s string) {
+	w.writeLine('+', s)
+}
+
+func (w *respWriter) writeError(s string) {
+	w.writeLine('-', s)
+}
+
+func (w *respWriter) writeInt(i int64) {
+	w.writeLine(':', strconv.FormatInt(i, 10))
+}
+
+func (w *respWriter) writeBulk(s string) {
+	w.writeLine('$', strconv.Itoa(len(s)))
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *respWriter) writeNull() {
+	if w.proto >= 3 {
+		w.w.WriteString("_\r\n")
+	} else {
+		w.w.WriteString("$-1\r\n")
+	}
+}
+
+func (w *respWriter) writeNullArray() {
+	if w.proto >= 3 {
+		w.w.WriteString("_\r\n")
+	} else {
+		w.w.WriteString("*-1\r\n")
+	}
+}
+
+func (w *respWriter) writeArrayLen(n int) {
+	w.writeLine('*', strconv.Itoa(n))
+}
+
+func (w *respWriter) writeStrings(values []string) {
+	w.writeArrayLen(len(values))
+	for _, value := range values {
+		w.writeBulk(value)
+	}
+}
+
+func (w *respWriter) writeMapLen(n int) {
+	if w.proto >= 3 {
+		w.writeLine('%', strconv.Itoa(n))
+	} else {
+		w.writeArrayLen(n * 2)
+	}
+}
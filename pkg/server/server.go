@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/razzie/razcache"
+)
+
+var ErrServerClosed = errors.New("server is closed")
+
+// Server serves a razcache.ExtendedCache over the Redis protocol (RESP2/RESP3)
+type Server struct {
+	cache     razcache.ExtendedCache
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+func NewServer(cache razcache.ExtendedCache) *Server {
+	return &Server{
+		cache:     cache,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener until it fails or the server is closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.trackConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) trackConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	s.wg.Done()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.untrackConn(conn)
+	defer conn.Close()
+
+	r := &respReader{r: bufio.NewReader(conn)}
+	w := &respWriter{w: bufio.NewWriter(conn), proto: 2}
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			if err == errProtocol {
+				w.writeError("ERR Protocol error")
+				w.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := s.execute(w, args)
+		// flush only after the pipelined commands are processed
+		if quit || r.r.Buffered() == 0 {
+			if err := w.w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// Close stops the listeners and closes the client connections, but not the cache
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var errs []error
+	for l := range s.listeners {
+		if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,258 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	. "github.com/razzie/razcache/pkg/server"
+)
+
+func startServer(t *testing.T) string {
+	return serveCache(t, inmem.NewInMemExtendedCache())
+}
+
+func serveCache(t *testing.T, cache razcache.ExtendedCache) string {
+	srv := NewServer(cache)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		assert.Equal(t, ErrServerClosed, <-done)
+		cache.Close()
+	})
+	return l.Addr().String()
+}
+
+func newClient(t *testing.T, addr string, protocol int) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: protocol})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestServer(t *testing.T) {
+	addr := startServer(t)
+	for _, protocol := range []int{2, 3} {
+		client := newClient(t, addr, protocol)
+		client.FlushDB(context.Background()) // unknown command, but shouldn't break the connection
+		testCommands(t, client)
+		client.Del(context.Background(), "str", "list", "set", "counter")
+	}
+}
+
+func testCommands(t *testing.T, client *redis.Client) {
+	ctx := context.Background()
+
+	assert.Equal(t, "PONG", client.Ping(ctx).Val())
+	assert.Equal(t, "hello", client.Echo(ctx, "hello").Val())
+
+	// strings and TTLs
+	assert.Equal(t, redis.Nil, client.Get(ctx, "str").Err())
+	assert.NoError(t, client.Set(ctx, "str", "value", 0).Err())
+	assert.Equal(t, "value", client.Get(ctx, "str").Val())
+	assert.Equal(t, time.Duration(-1), client.TTL(ctx, "str").Val())
+	assert.True(t, client.Expire(ctx, "str", time.Minute).Val())
+	assert.Equal(t, time.Minute, client.TTL(ctx, "str").Val())
+	assert.True(t, client.Persist(ctx, "str").Val())
+	assert.False(t, client.Persist(ctx, "str").Val())
+	assert.NoError(t, client.Set(ctx, "str", "value", 1500*time.Millisecond).Err())
+	assert.InDelta(t, 1500*time.Millisecond, client.PTTL(ctx, "str").Val(), float64(100*time.Millisecond))
+	assert.Equal(t, "value", client.GetEx(ctx, "str", time.Minute).Val())
+	assert.Equal(t, time.Minute, client.TTL(ctx, "str").Val())
+	assert.Equal(t, "value", client.GetEx(ctx, "str", 0).Val())
+	assert.Equal(t, time.Duration(-1), client.TTL(ctx, "str").Val())
+	assert.Equal(t, "value", client.GetDel(ctx, "str").Val())
+	assert.Equal(t, redis.Nil, client.GetDel(ctx, "str").Err())
+	assert.Equal(t, redis.Nil, client.GetEx(ctx, "str", time.Minute).Err())
+	assert.NoError(t, client.Set(ctx, "str", "value", 1500*time.Millisecond).Err())
+	assert.Equal(t, time.Duration(-2), client.TTL(ctx, "missing").Val())
+	assert.False(t, client.Expire(ctx, "missing", time.Minute).Val())
+	assert.Equal(t, int64(1), client.Exists(ctx, "str", "missing").Val())
+	assert.Equal(t, int64(1), client.Del(ctx, "str", "missing").Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "str").Val())
+
+	// lists
+	assert.Equal(t, int64(1), client.LPush(ctx, "list", "3").Val())
+	assert.Equal(t, int64(3), client.LPush(ctx, "list", "2", "1").Val())
+	assert.Equal(t, int64(5), client.RPush(ctx, "list", "4", "5").Val())
+	assert.Equal(t, int64(5), client.LLen(ctx, "list").Val())
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, client.LRange(ctx, "list", 0, -1).Val())
+	assert.Equal(t, "1", client.LPop(ctx, "list").Val())
+	assert.Equal(t, []string{"5", "4"}, client.RPopCount(ctx, "list", 2).Val())
+	assert.Equal(t, []string{"2", "3"}, client.LPopCount(ctx, "list", 5).Val())
+	assert.Equal(t, redis.Nil, client.LPop(ctx, "list").Err())
+	assert.Equal(t, redis.Nil, client.LPopCount(ctx, "list", 2).Err())
+
+	// sets
+	assert.Equal(t, int64(3), client.SAdd(ctx, "set", "a", "b", "c").Val())
+	assert.Equal(t, int64(1), client.SAdd(ctx, "set", "c", "d", "d").Val())
+	assert.Equal(t, int64(4), client.SCard(ctx, "set").Val())
+	assert.True(t, client.SIsMember(ctx, "set", "a").Val())
+	assert.Equal(t, int64(2), client.SRem(ctx, "set", "a", "d", "e").Val())
+	assert.False(t, client.SIsMember(ctx, "set", "a").Val())
+	assert.Equal(t, int64(2), client.SCard(ctx, "set").Val())
+	assert.ElementsMatch(t, []string{"b", "c"}, client.SMembers(ctx, "set").Val())
+
+	// counters
+	assert.Equal(t, int64(1), client.Incr(ctx, "counter").Val())
+	assert.Equal(t, int64(11), client.IncrBy(ctx, "counter", 10).Val())
+	assert.Equal(t, int64(10), client.Decr(ctx, "counter").Val())
+	assert.Equal(t, int64(5), client.DecrBy(ctx, "counter", 5).Val())
+	assert.Equal(t, "5", client.Get(ctx, "counter").Val())
+
+	// errors
+	err := client.LPush(ctx, "set", "a").Err()
+	assert.ErrorContains(t, err, "WRONGTYPE")
+	err = client.Do(ctx, "GET").Err()
+	assert.ErrorContains(t, err, "wrong number of arguments")
+	err = client.Do(ctx, "SET", "str", "value", "EX", "0").Err()
+	assert.ErrorContains(t, err, "invalid expire time")
+	err = client.Do(ctx, "SET", "str", "value", "NX").Err()
+	assert.ErrorContains(t, err, "syntax error")
+	err = client.Do(ctx, "SET", "str", "value", "EX", "9223372036854775807").Err()
+	assert.ErrorContains(t, err, "not an integer")
+	err = client.Do(ctx, "SET", "str", "value", "PX", "9223372036854775807").Err()
+	assert.ErrorContains(t, err, "not an integer")
+	assert.Equal(t, redis.Nil, client.Get(ctx, "str").Err())
+}
+
+func TestServerInline(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	readLine := func() string {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		return line
+	}
+
+	// inline and pipelined commands should work
+	_, err = conn.Write([]byte("SET key value\r\nGET key\r\nGET missing\r\nNOPE\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", readLine())
+	assert.Equal(t, "$5\r\n", readLine())
+	assert.Equal(t, "value\r\n", readLine())
+	assert.Equal(t, "$-1\r\n", readLine())
+	assert.Equal(t, "-ERR unknown command 'NOPE'\r\n", readLine())
+
+	// RESP3 nulls after HELLO
+	_, err = conn.Write([]byte("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n*2\r\n$3\r\nGET\r\n$7\r\nmissing\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "%7\r\n", readLine())
+	for i := 0; i < 14; i++ {
+		line := readLine()
+		if line[0] == '$' {
+			readLine()
+		}
+	}
+	assert.Equal(t, "_\r\n", readLine())
+
+	_, err = conn.Write([]byte("QUIT\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", readLine())
+	_, err = r.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestServerInlineLimit(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// lines longer than 64KB aren't buffered, the connection is closed instead
+	_, err = conn.Write([]byte("GET " + strings.Repeat("a", 64*1024) + "\r\n"))
+	require.NoError(t, err)
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "-ERR Protocol error\r\n", line)
+	_, err = r.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestServerScan(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, startServer(t), 2)
+
+	long := strings.Repeat("a", 100)
+	for _, key := range []string{"user:1", "user:2", "user:10", "order:1", "u[1]", long} {
+		require.NoError(t, client.Set(ctx, key, "value", 0).Err())
+	}
+
+	scan := func(pattern string) []string {
+		keys, cursor, err := client.Scan(ctx, 0, pattern, 0).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), cursor)
+		return keys
+	}
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:10", "order:1", "u[1]", long}, scan(""))
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:10"}, scan("user:*"))
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, scan("user:?"))
+	assert.ElementsMatch(t, []string{"user:1", "order:1"}, scan("*r:1"))
+	assert.ElementsMatch(t, []string{"user:2"}, scan("user:[^1]"))
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, scan("user:[1-2]"))
+	assert.ElementsMatch(t, []string{"u[1]"}, scan(`u\[1\]`))
+	assert.ElementsMatch(t, []string{"user:1", "user:10", "order:1"}, scan("*r*1*"))
+	assert.ElementsMatch(t, []string{long}, scan("a*a*a"))
+
+	// patterns with many stars don't backtrack exponentially
+	assert.Empty(t, scan(strings.Repeat("a*", 30)+"b"))
+}
+
+func TestServerBulkLimit(t *testing.T) {
+	addr := startServer(t)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// lengths in headers don't allocate memory before the data arrives
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = conn.Write([]byte("*1048576\r\n$536870912\r\nabc"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err)
+	runtime.ReadMemStats(&after)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(16*1024*1024))
+}
+
+func TestServerWrappedErrors(t *testing.T) {
+	// errors of backends wrap the sentinel errors
+	cache := razcache.NewMiddlewareExtendedCache(inmem.NewInMemExtendedCache(), func(next razcache.Handler) razcache.Handler {
+		return func(op *razcache.Operation) {
+			next(op)
+			if op.Err != nil {
+				op.Err = &razcache.Error{Op: op.Name, Backend: "test", Err: op.Err}
+			}
+		}
+	})
+	client := newClient(t, serveCache(t, cache), 2)
+	ctx := context.Background()
+
+	assert.Equal(t, redis.Nil, client.Get(ctx, "missing").Err())
+	assert.Equal(t, time.Duration(-2), client.TTL(ctx, "missing").Val())
+	assert.Equal(t, int64(0), client.Exists(ctx, "missing").Val())
+	require.NoError(t, client.Set(ctx, "str", "value", 0).Err())
+	err := client.LPush(ctx, "str", "a").Err()
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "WRONGTYPE"), err.Error())
+	}
+}
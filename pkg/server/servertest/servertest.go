@@ -0,0 +1,50 @@
+// Package servertest provides an in-process Redis protocol server backed by
+// pkg/inmem, so Redis clients can be tested without a Redis instance.
+package servertest
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/server"
+)
+
+type Server struct {
+	Addr  string // host:port on the loopback interface
+	Cache razcache.ExtendedCache
+
+	srv  *server.Server
+	done chan error
+}
+
+// NewServer starts a server on a random loopback port. It panics if it
+// cannot listen, like httptest.NewServer.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("servertest: failed to listen: %v", err))
+	}
+	cache := inmem.NewInMemExtendedCache()
+	s := &Server{
+		Addr:  l.Addr().String(),
+		Cache: cache,
+		srv:   server.NewServer(cache),
+		done:  make(chan error, 1),
+	}
+	go func() { s.done <- s.srv.Serve(l) }()
+	return s
+}
+
+// URL returns a DSN that can be passed to redis.ParseURL
+func (s *Server) URL() string {
+	return "redis://" + s.Addr
+}
+
+// Close stops the server and closes the cache
+func (s *Server) Close() {
+	s.srv.Close()
+	<-s.done
+	s.Cache.Close()
+}
@@ -0,0 +1,218 @@
+package testutil
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+// BenchFactory returns a new, empty cache for each benchmark.
+// The caches are closed by the benchmarks.
+type BenchFactory func(b *testing.B) razcache.Cache
+
+// Benchmark is a single benchmark of the suite
+type Benchmark struct {
+	Name     string
+	Requires razcache.Capability
+	Run      func(b *testing.B, cache razcache.Cache)
+}
+
+var benchmarks = []Benchmark{
+	{Name: "Set/size=16", Run: benchSet(16)},
+	{Name: "Set/size=1024", Run: benchSet(1024)},
+	{Name: "Set/size=65536", Run: benchSet(65536)},
+	{Name: "SetParallel", Run: benchMixed(0)},
+	{Name: "Get/keys=100", Run: benchGet(100)},
+	{Name: "Get/keys=10000", Run: benchGet(10000)},
+	{Name: "GetParallel", Run: benchMixed(100)},
+	{Name: "AppendBytes", Run: benchAppendBytes},
+	{Name: "Mixed/read=90%", Run: benchMixed(90)},
+	{Name: "Mixed/read=50%", Run: benchMixed(50)},
+	{Name: "TTLChurn", Run: benchTTLChurn},
+	{Name: "Incr", Requires: razcache.CapCounters, Run: benchIncr},
+	{Name: "IncrParallel", Requires: razcache.CapCounters, Run: benchIncrParallel},
+	{Name: "LPush", Requires: razcache.CapLists, Run: benchLPush},
+	{Name: "SAdd", Requires: razcache.CapSets, Run: benchSAdd},
+}
+
+// Benchmarks returns the benchmark suite, e.g. to run it with testing.Benchmark
+func Benchmarks() []Benchmark {
+	return benchmarks
+}
+
+// RunBenchmarks runs the benchmark suite as sub-benchmarks, each of them against
+// a new cache returned by the factory. Benchmarks are skipped for caches
+// that don't report the capabilities they require.
+func RunBenchmarks(b *testing.B, factory BenchFactory) {
+	for _, bm := range benchmarks {
+		b.Run(bm.Name, func(b *testing.B) {
+			cache := factory(b)
+			defer cache.Close()
+			if have := razcache.Capabilities(cache); !have.Has(bm.Requires) {
+				b.Skipf("missing capabilities: %s", bm.Requires&^have)
+			}
+			bm.Run(b, cache)
+		})
+	}
+}
+
+const benchKeySpace = 10000
+
+var benchKeys = func() []string {
+	keys := make([]string, benchKeySpace)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	return keys
+}()
+
+func benchSet(size int) func(b *testing.B, cache razcache.Cache) {
+	return func(b *testing.B, cache razcache.Cache) {
+		value := strings.Repeat("x", size)
+		b.SetBytes(int64(size))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := cache.Set(benchKeys[i%benchKeySpace], value, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchPopulate(b *testing.B, cache razcache.Cache, keys int) {
+	value := strings.Repeat("x", 64)
+	for _, key := range benchKeys[:keys] {
+		if err := cache.Set(key, value, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchGet(keys int) func(b *testing.B, cache razcache.Cache) {
+	return func(b *testing.B, cache razcache.Cache) {
+		benchPopulate(b, cache, keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := cache.Get(benchKeys[i%keys]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// benchAppendBytes reads 1KiB values into a reused buffer
+func benchAppendBytes(b *testing.B, cache razcache.Cache) {
+	value := make([]byte, 1024)
+	for i := 0; i < 100; i++ {
+		if err := razcache.SetBytes(cache, benchKeys[i], value, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	buf := make([]byte, 0, len(value))
+	b.SetBytes(int64(len(value)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = razcache.AppendBytes(cache, buf[:0], benchKeys[i%100]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchMixed runs parallel reads and writes, where readPercent of the operations are reads
+func benchMixed(readPercent int) func(b *testing.B, cache razcache.Cache) {
+	return func(b *testing.B, cache razcache.Cache) {
+		benchPopulate(b, cache, benchKeySpace)
+		value := strings.Repeat("x", 64)
+		var seed atomic.Int64
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			// each goroutine starts at a different key
+			i := int(seed.Add(7919))
+			for pb.Next() {
+				key := benchKeys[i%benchKeySpace]
+				var err error
+				if i%100 < readPercent {
+					_, err = cache.Get(key)
+				} else {
+					err = cache.Set(key, value, 0)
+				}
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				i++
+			}
+		})
+	}
+}
+
+// benchTTLChurn keeps setting and updating TTLs without letting keys expire
+func benchTTLChurn(b *testing.B, cache razcache.Cache) {
+	value := strings.Repeat("x", 64)
+	var seed atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(seed.Add(7919))
+		for pb.Next() {
+			key := benchKeys[i%benchKeySpace]
+			ttl := time.Hour + time.Duration(i%1000)*time.Second
+			var err error
+			if i%2 == 0 {
+				err = cache.Set(key, value, ttl)
+			} else if err = cache.SetTTL(key, ttl); errors.Is(err, razcache.ErrNotFound) {
+				err = nil
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
+
+func benchIncr(b *testing.B, cache razcache.Cache) {
+	ext := cache.(razcache.ExtendedCache)
+	for i := 0; i < b.N; i++ {
+		if _, err := ext.Incr("counter", 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchIncrParallel(b *testing.B, cache razcache.Cache) {
+	ext := cache.(razcache.ExtendedCache)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := ext.Incr("counter", 1); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func benchLPush(b *testing.B, cache razcache.Cache) {
+	ext := cache.(razcache.ExtendedCache)
+	value := strings.Repeat("x", 64)
+	for i := 0; i < b.N; i++ {
+		if err := ext.LPush("list", value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchSAdd(b *testing.B, cache razcache.Cache) {
+	ext := cache.(razcache.ExtendedCache)
+	for i := 0; i < b.N; i++ {
+		if err := ext.SAdd("set", benchKeys[i%benchKeySpace]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
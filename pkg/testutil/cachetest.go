@@ -23,6 +23,16 @@ func TestBasic(t *testing.T, cache razcache.Cache) {
 }
 
 func TestTTL(t *testing.T, cache razcache.Cache, ttlGran time.Duration) {
+	testTTL(t, cache, ttlGran, nil)
+}
+
+// TestTTLWithClock is like TestTTL, but it advances clock instead of sleeping.
+// The cache has to use clock for its TTLs.
+func TestTTLWithClock(t *testing.T, cache razcache.Cache, clock *FakeClock) {
+	testTTL(t, cache, time.Second, clock)
+}
+
+func testTTL(t *testing.T, cache razcache.Cache, ttlGran time.Duration, clock *FakeClock) {
 	// key should be present before expiration and gone afterwards
 	assert.NoError(t, cache.Set("key1", "value1", ttlGran*3))
 	assert.NoError(t, cache.Set("key2", "value2", ttlGran))
@@ -34,24 +44,22 @@ func TestTTL(t *testing.T, cache razcache.Cache, ttlGran time.Duration) {
 	assert.NoError(t, err)
 	assert.Equal(t, "value2", value)
 
-	time.Sleep(ttlGran * 2)
+	wait(clock, ttlGran*2)
 
 	value, err = cache.Get("key1")
 	assert.NoError(t, err)
 	assert.Equal(t, "value1", value)
-	_, err = cache.Get("key2")
-	assert.Equal(t, razcache.ErrNotFound, err)
+	assertExpired(t, cache, "key2")
 
-	time.Sleep(ttlGran * 2)
+	wait(clock, ttlGran*2)
 
-	_, err = cache.Get("key1")
-	assert.Equal(t, razcache.ErrNotFound, err)
+	assertExpired(t, cache, "key1")
 
 	// overwritten key with different TTL should make the value persist
 	assert.NoError(t, cache.Set("key2", "value2", ttlGran))
 	assert.NoError(t, cache.Set("key2", "newvalue2", 0))
 
-	time.Sleep(ttlGran * 2)
+	wait(clock, ttlGran*2)
 
 	value, err = cache.Get("key2")
 	assert.NoError(t, err)
@@ -60,7 +68,21 @@ func TestTTL(t *testing.T, cache razcache.Cache, ttlGran time.Duration) {
 	// make sure janitor won't crash if key is removed before expiration
 	assert.NoError(t, cache.Set("key3", "value3", ttlGran))
 	assert.NoError(t, cache.Del("key3"))
-	time.Sleep(ttlGran * 2)
+	wait(clock, ttlGran*2)
+}
+
+// wait sleeps, or advances the clock if it's not nil
+func wait(clock *FakeClock, d time.Duration) {
+	if clock == nil {
+		time.Sleep(d)
+		return
+	}
+	clock.Advance(d)
+}
+
+func assertExpired(t *testing.T, cache razcache.Cache, key string) {
+	_, err := cache.Get(key)
+	assert.Equal(t, razcache.ErrNotFound, err, "%s should have expired", key)
 }
 
 func TestLists(t *testing.T, cache razcache.ExtendedCache) {
@@ -119,11 +141,21 @@ func TestSets(t *testing.T, cache razcache.ExtendedCache) {
 	slen, err = cache.SLen("set")
 	assert.NoError(t, err)
 	assert.Equal(t, 2, slen)
+	listsMembers := razcache.Capabilities(cache).Has(razcache.CapSetMembers)
+	if listsMembers {
+		members, err := razcache.SMembers(cache, "set")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"b", "c"}, members)
+	}
 
 	// testing set functions on non-set keys
 	assert.NoError(t, cache.Set("non-set", "value", 0))
 	_, err = cache.SLen("non-set")
 	assert.Equal(t, razcache.ErrWrongType, err)
+	if listsMembers {
+		_, err = razcache.SMembers(cache, "non-set")
+		assert.Equal(t, razcache.ErrWrongType, err)
+	}
 	assert.Equal(t, razcache.ErrWrongType, cache.SAdd("non-set", "a"))
 	assert.Equal(t, razcache.ErrWrongType, cache.SRem("non-set"))
 }
@@ -0,0 +1,93 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/razzie/razcache"
+)
+
+// FakeClock is a razcache.Clock that only moves when it's advanced.
+// Its timers fire during Advance, or immediately if they are reset to a non-positive duration.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+var _ razcache.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock that starts at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) razcache.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.reset(d)
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward and fires the timers that are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.fire()
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.reset(d)
+}
+
+func (t *fakeTimer) reset(d time.Duration) bool {
+	wasActive := t.active
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	if d <= 0 {
+		t.fire()
+	}
+	return wasActive
+}
+
+func (t *fakeTimer) fire() {
+	t.active = false
+	select {
+	case t.c <- t.clock.now:
+	default: // like time.Timer, don't block if the previous tick wasn't received
+	}
+}
@@ -0,0 +1,1026 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/razzie/razcache"
+)
+
+// Factory returns a new, empty cache for each conformance test.
+// The caches are closed by the tests.
+type Factory func(t *testing.T) razcache.Cache
+
+type ConformanceOption func(c *conformance)
+
+// WithTTLGranularity sets the smallest TTL the cache handles reliably (50ms by default)
+func WithTTLGranularity(ttlGran time.Duration) ConformanceOption {
+	return func(c *conformance) {
+		c.ttlGran = ttlGran
+	}
+}
+
+// WithClock makes TTL tests advance clock instead of sleeping.
+// The caches returned by the factory have to use clock for their TTLs.
+func WithClock(clock *FakeClock) ConformanceOption {
+	return func(c *conformance) {
+		c.clock = clock
+	}
+}
+
+// WithStress sets the number of goroutines and operations per goroutine
+// of the concurrent stress test (8 and 100 by default)
+func WithStress(goroutines, ops int) ConformanceOption {
+	return func(c *conformance) {
+		c.goroutines = goroutines
+		c.ops = ops
+	}
+}
+
+// WithSkip skips the named tests for caches that deliberately behave differently
+func WithSkip(reason string, tests ...string) ConformanceOption {
+	return func(c *conformance) {
+		for _, test := range tests {
+			c.skip[test] = reason
+		}
+	}
+}
+
+type conformance struct {
+	factory    Factory
+	ttlGran    time.Duration
+	clock      *FakeClock
+	goroutines int
+	ops        int
+	skip       map[string]string
+}
+
+// cache creates a new cache that is closed at the end of the test
+func (c *conformance) cache(t *testing.T) razcache.Cache {
+	cache := c.factory(t)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+// now returns the time of the fake clock if there's one
+func (c *conformance) now() time.Time {
+	if c.clock != nil {
+		return c.clock.Now()
+	}
+	return time.Now()
+}
+
+// extCache is like cache, but it skips the test if the cache doesn't have the capabilities of an ExtendedCache
+func (c *conformance) extCache(t *testing.T) razcache.ExtendedCache {
+	cache := c.cache(t)
+	requireCaps(t, cache, razcache.CapExtended)
+	ext, ok := cache.(razcache.ExtendedCache)
+	if !ok {
+		t.Skip("not an ExtendedCache")
+	}
+	return ext
+}
+
+// requireCaps skips the test unless the cache has the capabilities
+func requireCaps(t *testing.T, cache razcache.Cache, caps razcache.Capability) {
+	if have := razcache.Capabilities(cache); !have.Has(caps) {
+		t.Skipf("missing capabilities: %s", caps&^have)
+	}
+}
+
+// asExt returns the cache as an ExtendedCache if it has the capabilities of one
+func asExt(cache razcache.Cache) (razcache.ExtendedCache, bool) {
+	if !razcache.Capabilities(cache).Has(razcache.CapExtended) {
+		return nil, false
+	}
+	ext, ok := cache.(razcache.ExtendedCache)
+	return ext, ok
+}
+
+var conformanceTests = []struct {
+	name string
+	run  func(t *testing.T, c *conformance)
+}{
+	{"Basic", func(t *testing.T, c *conformance) { TestBasic(t, c.cache(t)) }},
+	{"EmptyValue", testEmptyValue},
+	{"HugeValue", testHugeValue},
+	{"UnicodeKeys", testUnicodeKeys},
+	{"Del", testDel},
+	{"TTL", func(t *testing.T, c *conformance) { testTTL(t, c.cache(t), c.ttlGran, c.clock) }},
+	{"GetSetTTL", testGetSetTTL},
+	{"TTLContract", testTTLContract},
+	{"SubSecondTTL", testSubSecondTTL},
+	{"Expirer", testExpirer},
+	{"GetEx", testGetEx},
+	{"Bytes", testBytes},
+	{"PrefixIsolation", testPrefixIsolation},
+	{"Close", testClose},
+	{"Scan", testScan},
+	{"Lists", func(t *testing.T, c *conformance) { TestLists(t, c.extCache(t)) }},
+	{"ListEdgeCases", testListEdgeCases},
+	{"ListDel", testListDel},
+	{"Sets", func(t *testing.T, c *conformance) { TestSets(t, c.extCache(t)) }},
+	{"SetEdgeCases", testSetEdgeCases},
+	{"SetMembers", testSetMembers},
+	{"SetCounts", testSetCounts},
+	{"ReadsDontCreateKeys", testReadsDontCreateKeys},
+	{"EmptyCollectionsVanish", testEmptyCollectionsVanish},
+	{"ConcurrentPushPop", testConcurrentPushPop},
+	{"Incr", func(t *testing.T, c *conformance) { TestIncr(t, c.extCache(t)) }},
+	{"IncrEdgeCases", testIncrEdgeCases},
+	{"WrongType", testWrongType},
+	{"ConcurrentStress", testConcurrentStress},
+}
+
+// RunConformance runs the complete table of cache behaviors as subtests,
+// each of them against a new cache returned by the factory.
+// Tests are skipped for caches that don't report the capabilities they require.
+func RunConformance(t *testing.T, factory Factory, opts ...ConformanceOption) {
+	c := &conformance{
+		factory:    factory,
+		ttlGran:    50 * time.Millisecond,
+		goroutines: 8,
+		ops:        100,
+		skip:       make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for _, test := range conformanceTests {
+		t.Run(test.name, func(t *testing.T) {
+			if reason, ok := c.skip[test.name]; ok {
+				t.Skip(reason)
+			}
+			test.run(t, c)
+		})
+	}
+}
+
+func testEmptyValue(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+
+	assert.NoError(t, cache.Set("key", "", 0))
+	value, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "", value)
+}
+
+func testHugeValue(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+
+	var sb strings.Builder
+	for i := 0; sb.Len() < 1<<19; i++ { // 512 KiB, as some backends limit values to 1 MiB
+		fmt.Fprintf(&sb, "%d,", i)
+	}
+	huge := sb.String()
+
+	assert.NoError(t, cache.Set("key", huge, 0))
+	value, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, value == huge, "huge value doesn't match")
+}
+
+func testUnicodeKeys(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+
+	keys := []string{"ключ", "键", "🔑", "key with spaces", "a/b?c&d=e%f#g", "tab\tnew\nline"}
+	for _, key := range keys {
+		assert.NoError(t, cache.Set(key, "value of "+key, 0))
+	}
+	for _, key := range keys {
+		value, err := cache.Get(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, "value of "+key, value)
+	}
+}
+
+func testDel(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assert.NoError(t, cache.Del("key"))
+	_, err := cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// deleting a missing key is not an error
+	assert.NoError(t, cache.Del("missing"))
+}
+
+func testGetSetTTL(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	ttl := c.ttlGran * 20
+
+	// remaining TTL should be positive but not more than the original,
+	// except for caches that round deadlines up to their granularity
+	assert.NoError(t, cache.Set("key", "value", ttl))
+	remaining, err := cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Greater(t, remaining, time.Duration(0))
+	assert.LessOrEqual(t, remaining, ttl+c.ttlGran)
+
+	// extending the TTL
+	assert.NoError(t, cache.SetTTL("key", ttl*2))
+	remaining, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Greater(t, remaining, ttl)
+
+	// keys without TTL report NoExpiry
+	assert.NoError(t, cache.Set("persistent", "value", 0))
+	remaining, err = cache.GetTTL("persistent")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, remaining)
+
+	// setting a TTL should make the key expire
+	assert.NoError(t, cache.SetTTL("persistent", c.ttlGran))
+	wait(c.clock, c.ttlGran*2)
+	assertExpired(t, cache, "persistent")
+}
+
+// testSubSecondTTL checks that TTLs below a second don't expire early in caches that store seconds,
+// at several offsets within the second
+func testSubSecondTTL(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	caps := razcache.Capabilities(cache)
+	ttl := 300 * time.Millisecond
+
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, cache.Set("set", "value", ttl))
+		assert.NoError(t, razcache.SetBytes(cache, "bytes", []byte("value"), ttl))
+		assert.NoError(t, cache.Set("setttl", "value", 0))
+		assert.NoError(t, cache.SetTTL("setttl", ttl))
+		assert.NoError(t, cache.Set("getex", "value", 0))
+		_, err := razcache.GetEx(cache, "getex", ttl)
+		assert.NoError(t, err)
+		keys := []string{"set", "bytes", "setttl", "getex"}
+		if caps.Has(razcache.CapExpire) {
+			assert.NoError(t, cache.Set("expire", "value", 0))
+			_, err = razcache.Expire(cache, "expire", ttl, razcache.ExpireAlways)
+			assert.NoError(t, err)
+			keys = append(keys, "expire")
+		}
+		for _, key := range keys {
+			value, err := cache.Get(key)
+			assert.NoError(t, err, key)
+			assert.Equal(t, "value", value, key)
+			remaining, err := cache.GetTTL(key)
+			assert.NoError(t, err, key)
+			assert.Greater(t, remaining, time.Duration(0), key)
+		}
+		wait(c.clock, 170*time.Millisecond)
+	}
+}
+
+func testTTLContract(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+
+	// missing keys
+	_, err := cache.GetTTL("missing")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	for _, ttl := range []time.Duration{time.Hour, 0, -time.Second} {
+		assert.Equal(t, razcache.ErrNotFound, cache.SetTTL("missing", ttl), ttl)
+	}
+
+	// a TTL of 0 persists the key
+	assert.NoError(t, cache.Set("key", "value", c.ttlGran))
+	assert.NoError(t, cache.SetTTL("key", 0))
+	ttl, err := cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+	assert.NoError(t, cache.SetTTL("key", 0)) // persisting again is not an error
+	wait(c.clock, c.ttlGran*2)
+	value, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// a sub-millisecond TTL is still positive
+	assert.NoError(t, cache.SetTTL("key", time.Microsecond))
+	ttl, err = cache.GetTTL("key")
+	if err == nil {
+		assert.Greater(t, ttl, time.Duration(0))
+	} else {
+		assert.Equal(t, razcache.ErrNotFound, err)
+	}
+
+	// a negative TTL deletes the key
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assert.NoError(t, cache.SetTTL("key", -time.Second))
+	_, err = cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	_, err = cache.GetTTL("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// Set with a negative TTL deletes the key instead of storing the value
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assert.NoError(t, cache.Set("key", "other", -time.Second))
+	_, err = cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, cache.Set("key", "other", -time.Second))
+
+	// Set without TTL removes the previous TTL
+	assert.NoError(t, cache.Set("key", "value", time.Hour))
+	assert.NoError(t, cache.Set("key", "value", 0))
+	ttl, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+
+	// collections follow the same rules
+	ext, ok := asExt(cache)
+	if !ok {
+		return
+	}
+	assert.NoError(t, ext.RPush("list", "a"))
+	ttl, err = ext.GetTTL("list")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+	assert.NoError(t, ext.SetTTL("list", time.Hour))
+	assert.NoError(t, ext.SetTTL("list", 0))
+	ttl, err = ext.GetTTL("list")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+	assert.NoError(t, ext.SetTTL("list", -1))
+	length, err := ext.LLen("list")
+	assert.NoError(t, err)
+	assert.Zero(t, length)
+}
+
+func testExpirer(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	requireCaps(t, cache, razcache.CapExpire)
+	ttl := c.ttlGran * 20
+	assertTTL := func(expected time.Duration) {
+		t.Helper()
+		remaining, err := cache.GetTTL("key")
+		assert.NoError(t, err)
+		if expected == razcache.NoExpiry {
+			assert.Equal(t, razcache.NoExpiry, remaining)
+		} else {
+			assert.InDelta(t, expected, remaining, float64(c.ttlGran))
+		}
+	}
+	assertExpire := func(ttl time.Duration, cond razcache.ExpireCondition, expected bool) {
+		t.Helper()
+		ok, err := razcache.Expire(cache, "key", ttl, cond)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ok, "%v %s", ttl, cond)
+	}
+
+	// missing keys
+	_, err := razcache.Expire(cache, "missing", ttl, razcache.ExpireAlways)
+	assert.Equal(t, razcache.ErrNotFound, err)
+	_, err = razcache.ExpireAt(cache, "missing", c.now().Add(ttl), razcache.ExpireNX)
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.Equal(t, razcache.ErrNotFound, razcache.Persist(cache, "missing"))
+
+	// SetKeepTTL sets missing keys without TTL, and keeps the TTL of existing ones
+	assert.NoError(t, razcache.SetKeepTTL(cache, "key", "value1"))
+	assertTTL(razcache.NoExpiry)
+	assert.NoError(t, cache.Set("key", "value2", ttl))
+	assert.NoError(t, razcache.SetKeepTTL(cache, "key", "value3"))
+	value, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value3", value)
+	assertTTL(ttl)
+	assert.NoError(t, razcache.Persist(cache, "key"))
+	assertTTL(razcache.NoExpiry)
+
+	// conditions
+	assertExpire(ttl, razcache.ExpireXX, false)
+	assertExpire(ttl, razcache.ExpireGT, false)
+	assertExpire(ttl, razcache.ExpireNX, true)
+	assertTTL(ttl)
+	assertExpire(ttl*2, razcache.ExpireNX, false)
+	assertExpire(ttl/2, razcache.ExpireGT, false)
+	assertExpire(ttl*2, razcache.ExpireGT, true)
+	assertTTL(ttl * 2)
+	assertExpire(ttl*3, razcache.ExpireLT, false)
+	assertExpire(ttl, razcache.ExpireLT, true)
+	assertTTL(ttl)
+	assertExpire(ttl*2, razcache.ExpireXX, true)
+	assertTTL(ttl * 2)
+	assert.NoError(t, razcache.Persist(cache, "key"))
+	assertExpire(ttl, razcache.ExpireLT, true)
+	assertTTL(ttl)
+
+	// absolute deadlines
+	ok, err := razcache.ExpireAt(cache, "key", c.now().Add(ttl*3), razcache.ExpireAlways)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assertTTL(ttl * 3)
+
+	// past deadlines delete the key, unless the condition doesn't allow it
+	ok, err = razcache.ExpireAt(cache, "key", c.now().Add(-time.Hour), razcache.ExpireNX)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assertTTL(ttl * 3)
+	ok, err = razcache.ExpireAt(cache, "key", c.now().Add(-time.Hour), razcache.ExpireAlways)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, err = cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, cache.Set("key", "value", 0))
+	assertExpire(0, razcache.ExpireAlways, true)
+	_, err = cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// the deadline is reached
+	assert.NoError(t, cache.Set("key", "value", 0))
+	ok, err = razcache.ExpireAt(cache, "key", c.now().Add(c.ttlGran), razcache.ExpireAlways)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	wait(c.clock, c.ttlGran*2)
+	assertExpired(t, cache, "key")
+}
+
+func testGetEx(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	ttl := c.ttlGran * 20
+
+	_, err := razcache.GetEx(cache, "key", ttl)
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	assert.NoError(t, cache.Set("key", "value", 0))
+	value, err := razcache.GetEx(cache, "key", ttl)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	remaining, err := cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.InDelta(t, ttl, remaining, float64(c.ttlGran))
+
+	value, err = razcache.GetEx(cache, "key", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	remaining, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, remaining)
+
+	value, err = razcache.GetEx(cache, "key", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	_, err = cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	// keys of the wrong type keep their TTL
+	if ext, ok := asExt(cache); ok {
+		assert.NoError(t, ext.RPush("list", "a"))
+		_, err = razcache.GetEx(ext, "list", ttl)
+		assert.Equal(t, razcache.ErrWrongType, err)
+		remaining, err = ext.GetTTL("list")
+		assert.NoError(t, err)
+		assert.Equal(t, razcache.NoExpiry, remaining)
+	}
+}
+
+func testBytes(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	binary := []byte{0, 1, 0xff, 0xfe, '\n', 0}
+
+	_, err := razcache.GetBytes(cache, "key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	dst := []byte("prefix")
+	result, err := razcache.AppendBytes(cache, dst, "key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.Equal(t, []byte("prefix"), result)
+
+	// the cache shouldn't keep the slice it was given
+	value := append([]byte(nil), binary...)
+	assert.NoError(t, razcache.SetBytes(cache, "key", value, 0))
+	value[0] = 'x'
+	result, err = razcache.GetBytes(cache, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, result)
+
+	// nor share the slices it returns
+	result[0] = 'x'
+	result, err = razcache.GetBytes(cache, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, result)
+
+	result, err = razcache.AppendBytes(cache, dst, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), binary...), result)
+
+	// string and byte slice values are interchangeable
+	str, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, string(binary), str)
+	assert.NoError(t, cache.Set("key", "value", 0))
+	result, err = razcache.GetBytes(cache, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), result)
+
+	assert.NoError(t, razcache.SetBytes(cache, "key", nil, 0))
+	result, err = razcache.GetBytes(cache, "key")
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	assert.NoError(t, razcache.SetBytes(cache, "key", binary, c.ttlGran))
+	wait(c.clock, c.ttlGran*2)
+	assertExpired(t, cache, "key")
+
+	if ext, ok := asExt(cache); ok {
+		assert.NoError(t, ext.RPush("list", "a"))
+		result, err = razcache.AppendBytes(ext, dst, "list")
+		assert.Equal(t, razcache.ErrWrongType, err)
+		assert.Equal(t, []byte("prefix"), result)
+
+		_, err = ext.Incr("counter", 42)
+		assert.NoError(t, err)
+		result, err = razcache.GetBytes(ext, "counter")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("42"), result)
+	}
+}
+
+func testPrefixIsolation(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	sub := cache.SubCache("a:")
+	nested := sub.SubCache("b:")
+
+	assert.NoError(t, sub.Set("key", "1", 0))
+	assert.NoError(t, nested.Set("key", "2", 0))
+
+	// keys of subcaches are prefixed in the parent
+	_, err := cache.Get("key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	value, err := cache.Get("a:key")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+	value, err = cache.Get("a:b:key")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+	value, err = sub.Get("b:key")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+
+	// deleting from the subcache affects the prefixed key only
+	assert.NoError(t, cache.Set("key", "0", 0))
+	assert.NoError(t, sub.Del("key"))
+	_, err = cache.Get("a:key")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	value, err = cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", value)
+
+	ext, ok := asExt(cache)
+	if !ok {
+		return
+	}
+	subExt := ext.SubExtendedCache("list:")
+	assert.NoError(t, subExt.RPush("key", "1", "2"))
+	length, err := ext.LLen("list:key")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, length)
+	_, err = ext.LLen("key")
+	assert.Equal(t, razcache.ErrWrongType, err) // "key" is still a string in the parent
+}
+
+func testClose(t *testing.T, c *conformance) {
+	cache := c.factory(t)
+
+	// closing a subcache shouldn't close the parent
+	sub := cache.SubCache("sub:")
+	assert.NoError(t, sub.Close())
+	assert.NoError(t, cache.Set("key", "value", 0))
+	value, err := cache.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// closed sub caches fail on their own
+	assert.NoError(t, sub.Close())
+	_, err = sub.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	assert.ErrorIs(t, sub.Set("key", "value", 0), razcache.ErrCacheClosed)
+
+	// closing is idempotent, and everything fails afterwards
+	assert.NoError(t, cache.Close())
+	assert.NoError(t, cache.Close())
+	_, err = cache.Get("key")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	assert.ErrorIs(t, cache.Set("key", "value", 0), razcache.ErrCacheClosed)
+	assert.ErrorIs(t, cache.Del("key"), razcache.ErrCacheClosed)
+	_, err = cache.GetTTL("key")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	assert.ErrorIs(t, cache.SetTTL("key", time.Minute), razcache.ErrCacheClosed)
+	_, err = cache.SubCache("sub:").Get("key")
+	assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	if ext, ok := asExt(cache); ok {
+		assert.ErrorIs(t, ext.LPush("list", "a"), razcache.ErrCacheClosed)
+		_, err = ext.LRange("list", 0, -1)
+		assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+		assert.ErrorIs(t, ext.SAdd("set", "a"), razcache.ErrCacheClosed)
+		_, err = ext.Incr("counter", 1)
+		assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	}
+	if razcache.Capabilities(cache).Has(razcache.CapScan) {
+		_, err = razcache.ScanKeys(cache, "")
+		assert.ErrorIs(t, err, razcache.ErrCacheClosed)
+	}
+}
+
+func testScan(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	requireCaps(t, cache, razcache.CapScan)
+
+	for _, key := range []string{"user:1", "user:2", "user:10", "order:1", "u"} {
+		require.NoError(t, cache.Set(key, "value", 0))
+	}
+
+	keys, err := razcache.ScanKeys(cache, "user:")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:10"}, keys)
+	keys, err = razcache.ScanKeys(cache, "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:10", "order:1", "u"}, keys)
+	keys, err = razcache.ScanKeys(cache, "missing:")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// subcaches scan without their prefix
+	keys, err = razcache.ScanKeys(cache.SubCache("user:"), "1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "10"}, keys)
+
+	// stopping early
+	var count int
+	assert.NoError(t, razcache.Scan(cache, "", func(string) bool {
+		count++
+		return false
+	}))
+	assert.Equal(t, 1, count)
+}
+
+func testListEdgeCases(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	// missing lists behave like empty ones
+	length, err := cache.LLen("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, length)
+	values, err := cache.LRange("missing", 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	values, err = cache.LPop("missing", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	// empty values are kept
+	assert.NoError(t, cache.RPush("list", "", "a", ""))
+	values, err = cache.LRange("list", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "a", ""}, values)
+
+	// pushing nothing is a no-op
+	assert.NoError(t, cache.RPush("list"))
+	assert.NoError(t, cache.LPush("list"))
+	length, err = cache.LLen("list")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, length)
+
+	// out of range indexes are clamped, reversed ranges are empty
+	values, err = cache.LRange("list", -100, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "a", ""}, values)
+	values, err = cache.LRange("list", 2, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	values, err = cache.LRange("list", -100, -50)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	// zero and negative counts pop nothing
+	values, err = cache.LPop("list", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+	values, err = cache.RPop("list", -1)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	// popping more than the length returns everything in list order
+	values, err = cache.RPop("list", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "a", ""}, values)
+	values, err = cache.LPop("list", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func testListDel(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	assert.NoError(t, cache.RPush("list", "1", "2"))
+	assert.NoError(t, cache.Del("list"))
+	length, err := cache.LLen("list")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, length)
+
+	// the key can be reused for another type
+	assert.NoError(t, cache.Set("list", "value", 0))
+	value, err := cache.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func testSetEdgeCases(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	// missing sets behave like empty ones
+	found, err := cache.SHas("missing", "a")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	length, err := cache.SLen("missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, length)
+
+	// duplicates and empty members
+	assert.NoError(t, cache.SAdd("set", "a", "a", ""))
+	length, err = cache.SLen("set")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, length)
+	found, err = cache.SHas("set", "")
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	// removing missing members is not an error
+	assert.NoError(t, cache.SRem("set", "missing"))
+	length, err = cache.SLen("set")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, length)
+}
+
+func testSetMembers(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+	requireCaps(t, cache, razcache.CapSetMembers)
+
+	// listing a missing set doesn't create it
+	members, err := razcache.SMembers(cache, "missing")
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+	_, err = cache.Get("missing")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	assert.NoError(t, cache.SAdd("set", "a", "a", ""))
+	assert.NoError(t, cache.SRem("set", "missing"))
+	members, err = razcache.SMembers(cache, "set")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", ""}, members)
+
+	assert.NoError(t, cache.Set("string", "value", 0))
+	_, err = razcache.SMembers(cache, "string")
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func testSetCounts(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	added, err := razcache.SAddCount(cache, "set", "a", "b", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+	added, err = razcache.SAddCount(cache, "set", "b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	removed, err := razcache.SRemCount(cache, "set", "a", "a", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	removed, err = razcache.SRemCount(cache, "set", "b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	_, err = cache.Get("set")
+	assert.Equal(t, razcache.ErrNotFound, err)
+
+	assert.NoError(t, cache.Set("string", "value", 0))
+	_, err = razcache.SAddCount(cache, "string", "a")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = razcache.SRemCount(cache, "string", "a")
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func testReadsDontCreateKeys(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	_, err := cache.LLen("llen")
+	assert.NoError(t, err)
+	_, err = cache.LRange("lrange", 0, -1)
+	assert.NoError(t, err)
+	_, err = cache.LPop("lpop", 1)
+	assert.NoError(t, err)
+	_, err = cache.RPop("rpop", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, cache.RPush("rpush"))
+	_, err = cache.SHas("shas", "a")
+	assert.NoError(t, err)
+	_, err = cache.SLen("slen")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.SRem("srem", "a"))
+	assert.NoError(t, cache.SAdd("sadd"))
+
+	for _, key := range []string{"llen", "lrange", "lpop", "rpop", "rpush", "shas", "slen", "srem", "sadd"} {
+		_, err = cache.Get(key)
+		assert.Equal(t, razcache.ErrNotFound, err, key)
+	}
+	keys, err := razcache.ScanKeys(cache, "")
+	if !errors.Is(err, razcache.ErrNotSupported) {
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+}
+
+func testEmptyCollectionsVanish(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	// popping the last values deletes the list and its TTL
+	assert.NoError(t, cache.RPush("list", "a", "b", "c"))
+	assert.NoError(t, cache.SetTTL("list", time.Hour))
+	_, err := cache.LPop("list", 1)
+	assert.NoError(t, err)
+	_, err = cache.RPop("list", 5)
+	assert.NoError(t, err)
+	_, err = cache.Get("list")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, cache.RPush("list", "d"))
+	ttl, err := cache.GetTTL("list")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+
+	// removing the last members deletes the set and its TTL
+	assert.NoError(t, cache.SAdd("set", "a", "b"))
+	assert.NoError(t, cache.SetTTL("set", time.Hour))
+	assert.NoError(t, cache.SRem("set", "a"))
+	assert.NoError(t, cache.SRem("set", "b", "c"))
+	_, err = cache.Get("set")
+	assert.Equal(t, razcache.ErrNotFound, err)
+	assert.NoError(t, cache.SAdd("set", "d"))
+	ttl, err = cache.GetTTL("set")
+	assert.NoError(t, err)
+	assert.Equal(t, razcache.NoExpiry, ttl)
+
+	keys, err := razcache.ScanKeys(cache, "")
+	if !errors.Is(err, razcache.ErrNotSupported) {
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"list", "set"}, keys)
+	}
+}
+
+func testConcurrentPushPop(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	// every pop follows a push, so none of them can find the list empty,
+	// even if another goroutine has just emptied and deleted it
+	var wg sync.WaitGroup
+	for g := 0; g < c.goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < c.ops; i++ {
+				value := fmt.Sprintf("%d:%d", g, i)
+				assert.NoError(t, cache.RPush("list", value))
+				values, err := cache.LPop("list", 1)
+				assert.NoError(t, err)
+				assert.Len(t, values, 1)
+
+				assert.NoError(t, cache.SAdd("set", value))
+				found, err := cache.SHas("set", value)
+				assert.NoError(t, err)
+				assert.True(t, found)
+				assert.NoError(t, cache.SRem("set", value))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for _, key := range []string{"list", "set"} {
+		_, err := cache.Get(key)
+		assert.Equal(t, razcache.ErrNotFound, err, key)
+	}
+}
+
+func testIncrEdgeCases(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	value, err := cache.Incr("counter", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), value)
+	value, err = cache.Incr("counter", -5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-5), value)
+
+	assert.NoError(t, cache.Set("negative", "-10", 0))
+	value, err = cache.Incr("negative", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-7), value)
+
+	assert.NoError(t, cache.Set("empty", "", 0))
+	_, err = cache.Incr("empty", 1)
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func testWrongType(t *testing.T, c *conformance) {
+	cache := c.extCache(t)
+
+	assert.NoError(t, cache.RPush("list", "1"))
+	assert.NoError(t, cache.SAdd("set", "a"))
+
+	_, err := cache.Get("list")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = cache.Get("set")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = cache.LLen("set")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = cache.SLen("list")
+	assert.Equal(t, razcache.ErrWrongType, err)
+	_, err = cache.Incr("list", 1)
+	assert.Equal(t, razcache.ErrWrongType, err)
+
+	// Set replaces values of any type
+	assert.NoError(t, cache.Set("list", "value", 0))
+	value, err := cache.Get("list")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	_, err = cache.LLen("list")
+	assert.Equal(t, razcache.ErrWrongType, err)
+}
+
+func testConcurrentStress(t *testing.T, c *conformance) {
+	cache := c.cache(t)
+	ext, isExt := asExt(cache)
+
+	var wg sync.WaitGroup
+	for g := 0; g < c.goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < c.ops; i++ {
+				key := fmt.Sprintf("key:%d:%d", g, i)
+				assert.NoError(t, cache.Set(key, key, 0))
+				assert.NoError(t, cache.Set("shared", key, 0))
+				value, err := cache.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, value)
+				if isExt {
+					_, err = ext.Incr("counter", 1)
+					assert.NoError(t, err)
+					assert.NoError(t, ext.RPush("list", key))
+					assert.NoError(t, ext.SAdd("set", key))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	value, err := cache.Get("shared")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "key:"))
+	if !isExt {
+		return
+	}
+	total := c.goroutines * c.ops
+	counter, err := ext.Incr("counter", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(total), counter)
+
+	// reads of a counter race with incrementing it, so readers don't increment it themselves
+	writers := 0
+	for g := 0; g < max(c.goroutines, 2); g++ {
+		reader := g%2 == 1
+		if !reader {
+			writers++
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < c.ops; i++ {
+				if !reader {
+					_, err := ext.Incr("counter", 1)
+					assert.NoError(t, err)
+					continue
+				}
+				value, err := cache.Get("counter")
+				assert.NoError(t, err)
+				_, err = strconv.ParseInt(value, 10, 64)
+				assert.NoError(t, err)
+				value, err = razcache.GetEx(cache, "counter", 0)
+				assert.NoError(t, err)
+				_, err = strconv.ParseInt(value, 10, 64)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	counter, err = ext.Incr("counter", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(total+writers*c.ops), counter)
+	length, err := ext.LLen("list")
+	assert.NoError(t, err)
+	assert.Equal(t, total, length)
+	length, err = ext.SLen("set")
+	assert.NoError(t, err)
+	assert.Equal(t, total, length)
+}
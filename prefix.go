@@ -1,18 +1,32 @@
 package razcache
 
 import (
+	"sync/atomic"
 	"time"
 )
 
 type prefixCache struct {
 	cache  Cache
 	prefix string
+	closed atomic.Bool
 }
 
+// target returns the wrapped cache, or a closed one once the prefix cache is closed
+func (c *prefixCache) target() Cache {
+	if c.closed.Load() {
+		return closedCache{}
+	}
+	return c.cache
+}
+
+// NewPrefixCache returns an ExtendedCache if the cache is one, so sub caches keep its capabilities
 func NewPrefixCache(cache Cache, prefix string) Cache {
 	if len(prefix) == 0 {
 		return cache
 	}
+	if ext, ok := cache.(ExtendedCache); ok {
+		return NewPrefixExtendedCache(ext, prefix)
+	}
 	return &prefixCache{
 		cache:  cache,
 		prefix: prefix,
@@ -20,29 +34,71 @@ func NewPrefixCache(cache Cache, prefix string) Cache {
 }
 
 func (c *prefixCache) Set(key, value string, ttl time.Duration) error {
-	return c.cache.Set(c.prefix+key, value, ttl)
+	return c.target().Set(c.prefix+key, value, ttl)
 }
 
 func (c *prefixCache) Get(key string) (string, error) {
-	return c.cache.Get(c.prefix + key)
+	return c.target().Get(c.prefix + key)
 }
 
 func (c *prefixCache) Del(key string) error {
-	return c.cache.Del(c.prefix + key)
+	return c.target().Del(c.prefix + key)
+}
+
+func (c *prefixCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return SetBytes(c.target(), c.prefix+key, value, ttl)
+}
+
+func (c *prefixCache) GetBytes(key string) ([]byte, error) {
+	return GetBytes(c.target(), c.prefix+key)
+}
+
+func (c *prefixCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	return AppendBytes(c.target(), dst, c.prefix+key)
 }
 
 func (c *prefixCache) GetTTL(key string) (time.Duration, error) {
-	return c.cache.GetTTL(c.prefix + key)
+	return c.target().GetTTL(c.prefix + key)
 }
 
 func (c *prefixCache) SetTTL(key string, ttl time.Duration) error {
-	return c.cache.SetTTL(c.prefix+key, ttl)
+	return c.target().SetTTL(c.prefix+key, ttl)
+}
+
+func (c *prefixCache) GetEx(key string, ttl time.Duration) (string, error) {
+	return GetEx(c.target(), c.prefix+key, ttl)
+}
+
+func (c *prefixCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	return Expire(c.target(), c.prefix+key, ttl, cond)
+}
+
+func (c *prefixCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	return ExpireAt(c.target(), c.prefix+key, deadline, cond)
+}
+
+func (c *prefixCache) Persist(key string) error {
+	return Persist(c.target(), c.prefix+key)
+}
+
+func (c *prefixCache) SetKeepTTL(key, value string) error {
+	return SetKeepTTL(c.target(), c.prefix+key, value)
+}
+
+func (c *prefixCache) Scan(prefix string, fn func(key string) bool) error {
+	return scanPrefixed(c.target(), c.prefix, prefix, fn)
+}
+
+func (c *prefixCache) Capabilities() Capability {
+	return Capabilities(c.cache)
 }
 
 func (c *prefixCache) SubCache(prefix string) Cache {
 	return NewPrefixCache(c, prefix)
 }
 
+// Close doesn't close the wrapped cache, as other sub caches might share it
 func (c *prefixCache) Close() error {
+	c.closed.Store(true)
 	return nil
 }
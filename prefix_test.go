@@ -34,3 +34,23 @@ func TestPrefixCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "val_c", value)
 }
+
+func TestPrefixCacheScan(t *testing.T) {
+	cache := inmem.NewInMemCache()
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set("a", "val_a", 0))
+	assert.NoError(t, cache.Set("prefix:b", "val_b", 0))
+	assert.NoError(t, cache.Set("prefix:bc", "val_bc", 0))
+	assert.NoError(t, cache.Set("prefix:c", "val_c", 0))
+
+	// keys should be scanned without the prefix of the cache
+	subcache := NewPrefixCache(cache, "prefix:")
+	keys, err := ScanKeys(subcache, "b")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b", "bc"}, keys)
+
+	// caches without scanning support should return ErrNotSupported
+	_, err = ScanKeys(NewPrefixCache(unavailableCache{}, "prefix:"), "")
+	assert.Equal(t, ErrNotSupported, err)
+}
@@ -1,12 +1,22 @@
 package razcache
 
 import (
+	"sync/atomic"
 	"time"
 )
 
 type prefixExtCache struct {
 	cache  ExtendedCache
 	prefix string
+	closed atomic.Bool
+}
+
+// target returns the wrapped cache, or a closed one once the prefix cache is closed
+func (c *prefixExtCache) target() ExtendedCache {
+	if c.closed.Load() {
+		return closedCache{}
+	}
+	return c.cache
 }
 
 func NewPrefixExtendedCache(cache ExtendedCache, prefix string) ExtendedCache {
@@ -20,67 +30,119 @@ func NewPrefixExtendedCache(cache ExtendedCache, prefix string) ExtendedCache {
 }
 
 func (c *prefixExtCache) Set(key, value string, ttl time.Duration) error {
-	return c.cache.Set(c.prefix+key, value, ttl)
+	return c.target().Set(c.prefix+key, value, ttl)
 }
 
 func (c *prefixExtCache) Get(key string) (string, error) {
-	return c.cache.Get(c.prefix + key)
+	return c.target().Get(c.prefix + key)
 }
 
 func (c *prefixExtCache) Del(key string) error {
-	return c.cache.Del(c.prefix + key)
+	return c.target().Del(c.prefix + key)
+}
+
+func (c *prefixExtCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return SetBytes(c.target(), c.prefix+key, value, ttl)
+}
+
+func (c *prefixExtCache) GetBytes(key string) ([]byte, error) {
+	return GetBytes(c.target(), c.prefix+key)
+}
+
+func (c *prefixExtCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	return AppendBytes(c.target(), dst, c.prefix+key)
 }
 
 func (c *prefixExtCache) GetTTL(key string) (time.Duration, error) {
-	return c.cache.GetTTL(c.prefix + key)
+	return c.target().GetTTL(c.prefix + key)
 }
 
 func (c *prefixExtCache) SetTTL(key string, ttl time.Duration) error {
-	return c.cache.SetTTL(c.prefix+key, ttl)
+	return c.target().SetTTL(c.prefix+key, ttl)
 }
 
 func (c *prefixExtCache) LPush(key string, values ...string) error {
-	return c.cache.LPush(c.prefix+key, values...)
+	return c.target().LPush(c.prefix+key, values...)
 }
 
 func (c *prefixExtCache) RPush(key string, values ...string) error {
-	return c.cache.RPush(c.prefix+key, values...)
+	return c.target().RPush(c.prefix+key, values...)
 }
 
 func (c *prefixExtCache) LPop(key string, count int) ([]string, error) {
-	return c.cache.LPop(c.prefix+key, count)
+	return c.target().LPop(c.prefix+key, count)
 }
 
 func (c *prefixExtCache) RPop(key string, count int) ([]string, error) {
-	return c.cache.RPop(c.prefix+key, count)
+	return c.target().RPop(c.prefix+key, count)
 }
 
 func (c *prefixExtCache) LLen(key string) (int, error) {
-	return c.cache.LLen(c.prefix + key)
+	return c.target().LLen(c.prefix + key)
 }
 
 func (c *prefixExtCache) LRange(key string, start, stop int) ([]string, error) {
-	return c.cache.LRange(c.prefix+key, start, stop)
+	return c.target().LRange(c.prefix+key, start, stop)
 }
 
 func (c *prefixExtCache) SAdd(key string, values ...string) error {
-	return c.cache.SAdd(c.prefix+key, values...)
+	return c.target().SAdd(c.prefix+key, values...)
 }
 
 func (c *prefixExtCache) SRem(key string, values ...string) error {
-	return c.cache.SRem(c.prefix+key, values...)
+	return c.target().SRem(c.prefix+key, values...)
+}
+
+func (c *prefixExtCache) SAddCount(key string, values ...string) (int, error) {
+	return SAddCount(c.target(), c.prefix+key, values...)
+}
+
+func (c *prefixExtCache) SRemCount(key string, values ...string) (int, error) {
+	return SRemCount(c.target(), c.prefix+key, values...)
 }
 
 func (c *prefixExtCache) SHas(key, value string) (bool, error) {
-	return c.cache.SHas(c.prefix+key, value)
+	return c.target().SHas(c.prefix+key, value)
 }
 
 func (c *prefixExtCache) SLen(key string) (int, error) {
-	return c.cache.SLen(c.prefix + key)
+	return c.target().SLen(c.prefix + key)
+}
+
+func (c *prefixExtCache) SMembers(key string) ([]string, error) {
+	return SMembers(c.target(), c.prefix+key)
 }
 
 func (c *prefixExtCache) Incr(key string, increment int64) (int64, error) {
-	return c.cache.Incr(c.prefix+key, increment)
+	return c.target().Incr(c.prefix+key, increment)
+}
+
+func (c *prefixExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	return GetEx(c.target(), c.prefix+key, ttl)
+}
+
+func (c *prefixExtCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	return Expire(c.target(), c.prefix+key, ttl, cond)
+}
+
+func (c *prefixExtCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	return ExpireAt(c.target(), c.prefix+key, deadline, cond)
+}
+
+func (c *prefixExtCache) Persist(key string) error {
+	return Persist(c.target(), c.prefix+key)
+}
+
+func (c *prefixExtCache) SetKeepTTL(key, value string) error {
+	return SetKeepTTL(c.target(), c.prefix+key, value)
+}
+
+func (c *prefixExtCache) Scan(prefix string, fn func(key string) bool) error {
+	return scanPrefixed(c.target(), c.prefix, prefix, fn)
+}
+
+func (c *prefixExtCache) Capabilities() Capability {
+	return Capabilities(c.cache)
 }
 
 func (c *prefixExtCache) SubCache(prefix string) Cache {
@@ -91,6 +153,8 @@ func (c *prefixExtCache) SubExtendedCache(prefix string) ExtendedCache {
 	return NewPrefixExtendedCache(c, prefix)
 }
 
+// Close doesn't close the wrapped cache, as other sub caches might share it
 func (c *prefixExtCache) Close() error {
+	c.closed.Store(true)
 	return nil
 }
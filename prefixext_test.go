@@ -7,6 +7,7 @@ import (
 
 	. "github.com/razzie/razcache"
 	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
 )
 
 func TestPrefixExtendedCache(t *testing.T) {
@@ -34,3 +35,11 @@ func TestPrefixExtendedCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "val_c", value)
 }
+
+func TestPrefixExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		cache := inmem.NewInMemExtendedCache()
+		t.Cleanup(func() { cache.Close() })
+		return NewPrefixExtendedCache(cache, "prefix:")
+	})
+}
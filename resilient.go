@@ -0,0 +1,353 @@
+package razcache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 10 * time.Millisecond
+	defaultMaxBackoff     = time.Second
+	defaultOpenTimeout    = 5 * time.Second
+)
+
+type ResilienceOptions struct {
+	// Number of retries after the first failed attempt of a transient error
+	MaxRetries int
+	// Backoff before the first retry, doubled after every retry (default 10ms)
+	InitialBackoff time.Duration
+	// Upper limit of the backoff (default 1s)
+	MaxBackoff time.Duration
+	// Retry non-idempotent operations too (list push/pop and Incr)
+	RetryNonIdempotent bool
+	// Deadline of a single attempt, no deadline if zero
+	Timeout time.Duration
+	// Consecutive failures that open the circuit breaker, disabled if zero
+	FailureThreshold int
+	// Time the breaker stays open before letting a trial call through (default 5s)
+	OpenTimeout time.Duration
+	// Reports whether an error is worth retrying and counts as a failure
+	// (default: errors of kind ErrUnavailable or ErrTimeout)
+	IsTransient func(error) bool
+	// Cache used while the breaker is open or when an operation fails with
+	// a transient error. ExtendedCache operations only use it if it is an
+	// ExtendedCache too. Closing the resilient cache closes it as well.
+	Fallback Cache
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type resilience struct {
+	opts     ResilienceOptions
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func (r *resilience) init(opts ResilienceOptions) {
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = defaultInitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = defaultOpenTimeout
+	}
+	if opts.IsTransient == nil {
+		opts.IsTransient = isTransientError
+	}
+	r.opts = opts
+}
+
+func isTransientError(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout)
+}
+
+// close closes the cache and the fallback
+func (r *resilience) close(cache Cache) error {
+	err := cache.Close()
+	if r.opts.Fallback != nil {
+		err = errors.Join(err, r.opts.Fallback.Close())
+	}
+	return err
+}
+
+// allow reports whether a call can go through the breaker
+func (r *resilience) allow() bool {
+	if r.opts.FailureThreshold <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.state {
+	case breakerOpen:
+		if time.Since(r.openedAt) < r.opts.OpenTimeout {
+			return false
+		}
+		r.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false // only one trial call at a time
+	default:
+		return true
+	}
+}
+
+func (r *resilience) report(failed bool) {
+	if r.opts.FailureThreshold <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !failed {
+		r.state = breakerClosed
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.state == breakerHalfOpen || r.failures >= r.opts.FailureThreshold {
+		r.state = breakerOpen
+		r.openedAt = time.Now()
+	}
+}
+
+func resilientCall[R any](r *resilience, idempotent bool, call func() (R, error), fallback func() (R, error)) (result R, err error) {
+	if !r.allow() {
+		if fallback != nil {
+			return fallback()
+		}
+		err = ErrCircuitOpen
+		return
+	}
+	retries := r.opts.MaxRetries
+	if !idempotent && !r.opts.RetryNonIdempotent {
+		retries = 0
+	}
+	backoff := r.opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		result, err = callWithTimeout(call, r.opts.Timeout)
+		transient := r.opts.IsTransient(err)
+		if !transient || attempt >= retries {
+			r.report(transient)
+			break
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, r.opts.MaxBackoff)
+	}
+	if r.opts.IsTransient(err) && fallback != nil {
+		return fallback()
+	}
+	return
+}
+
+// callWithTimeout abandons the call if it doesn't return in time.
+// The call itself keeps running in the background until it returns.
+func callWithTimeout[R any](call func() (R, error), timeout time.Duration) (R, error) {
+	if timeout <= 0 {
+		return call()
+	}
+	type callResult struct {
+		value R
+		err   error
+	}
+	resultChan := make(chan callResult, 1)
+	go func() {
+		value, err := call()
+		resultChan <- callResult{value: value, err: err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-resultChan:
+		return result.value, result.err
+	case <-timer.C:
+		var zero R
+		return zero, ErrTimeout
+	}
+}
+
+func resilientExec(r *resilience, idempotent bool, call func() error, fallback func() error) error {
+	var fallbackCall func() (struct{}, error)
+	if fallback != nil {
+		fallbackCall = func() (struct{}, error) { return struct{}{}, fallback() }
+	}
+	_, err := resilientCall(r, idempotent, func() (struct{}, error) {
+		return struct{}{}, call()
+	}, fallbackCall)
+	return err
+}
+
+type resilientCache struct {
+	cache Cache
+	r     resilience
+}
+
+// NewResilientCache returns a cache that retries failed operations, enforces
+// a per-call timeout and fails fast with ErrCircuitOpen while the underlying
+// cache keeps failing
+func NewResilientCache(cache Cache, opts ResilienceOptions) Cache {
+	c := &resilientCache{cache: cache}
+	c.r.init(opts)
+	return c
+}
+
+func (c *resilientCache) fallback() Cache {
+	return c.r.opts.Fallback
+}
+
+func (c *resilientCache) Set(key, value string, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.Set(key, value, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.Set(key, value, ttl)
+	}, fallback)
+}
+
+func (c *resilientCache) Get(key string) (string, error) {
+	var fallback func() (string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (string, error) { return fb.Get(key) }
+	}
+	return resilientCall(&c.r, true, func() (string, error) {
+		return c.cache.Get(key)
+	}, fallback)
+}
+
+func (c *resilientCache) Del(key string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.Del(key) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.Del(key)
+	}, fallback)
+}
+
+func (c *resilientCache) GetTTL(key string) (time.Duration, error) {
+	var fallback func() (time.Duration, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (time.Duration, error) { return fb.GetTTL(key) }
+	}
+	return resilientCall(&c.r, true, func() (time.Duration, error) {
+		return c.cache.GetTTL(key)
+	}, fallback)
+}
+
+func (c *resilientCache) SetTTL(key string, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.SetTTL(key, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.SetTTL(key, ttl)
+	}, fallback)
+}
+
+func (c *resilientCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return SetBytes(fb, key, value, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return SetBytes(c.cache, key, value, ttl)
+	}, fallback)
+}
+
+func (c *resilientCache) GetBytes(key string) ([]byte, error) {
+	var fallback func() ([]byte, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]byte, error) { return GetBytes(fb, key) }
+	}
+	return resilientCall(&c.r, true, func() ([]byte, error) {
+		return GetBytes(c.cache, key)
+	}, fallback)
+}
+
+// AppendBytes doesn't pass dst to the underlying cache, as calls abandoned
+// after a timeout could still write into it
+func (c *resilientCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	value, err := c.GetBytes(key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
+
+func (c *resilientCache) GetEx(key string, ttl time.Duration) (string, error) {
+	var fallback func() (string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (string, error) { return GetEx(fb, key, ttl) }
+	}
+	return resilientCall(&c.r, true, func() (string, error) {
+		return GetEx(c.cache, key, ttl)
+	}, fallback)
+}
+
+func (c *resilientCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	var fallback func() (bool, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (bool, error) { return Expire(fb, key, ttl, cond) }
+	}
+	return resilientCall(&c.r, true, func() (bool, error) {
+		return Expire(c.cache, key, ttl, cond)
+	}, fallback)
+}
+
+func (c *resilientCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	var fallback func() (bool, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (bool, error) { return ExpireAt(fb, key, deadline, cond) }
+	}
+	return resilientCall(&c.r, true, func() (bool, error) {
+		return ExpireAt(c.cache, key, deadline, cond)
+	}, fallback)
+}
+
+func (c *resilientCache) Persist(key string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return Persist(fb, key) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return Persist(c.cache, key)
+	}, fallback)
+}
+
+func (c *resilientCache) SetKeepTTL(key, value string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return SetKeepTTL(fb, key, value) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return SetKeepTTL(c.cache, key, value)
+	}, fallback)
+}
+
+// Scan is forwarded without retries and timeout, so fn never sees a key twice
+// or gets called after Scan returned
+func (c *resilientCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.cache, prefix, fn)
+}
+
+func (c *resilientCache) Capabilities() Capability {
+	return Capabilities(c.cache) & capOptional
+}
+
+func (c *resilientCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *resilientCache) Close() error {
+	return c.r.close(c.cache)
+}
@@ -0,0 +1,144 @@
+package razcache_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+// flakyCache fails the first 'failures' calls of Get with err (errUnavailable by default),
+// then works normally
+type flakyCache struct {
+	Cache
+	failures atomic.Int32
+	calls    atomic.Int32
+	delay    time.Duration
+	err      error
+}
+
+func (c *flakyCache) Get(key string) (string, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	if c.failures.Add(-1) >= 0 {
+		if c.err != nil {
+			return "", c.err
+		}
+		return "", errUnavailable
+	}
+	return c.Cache.Get(key)
+}
+
+func TestResilientCache(t *testing.T) {
+	cache := NewResilientCache(inmem.NewInMemCache(), ResilienceOptions{MaxRetries: 2})
+	defer cache.Close()
+	testutil.TestBasic(t, cache)
+}
+
+func TestResilientCacheRetry(t *testing.T) {
+	flaky := &flakyCache{Cache: inmem.NewInMemCache()}
+	defer flaky.Close()
+	assert.NoError(t, flaky.Set("a", "val_a", 0))
+
+	cache := NewResilientCache(flaky, ResilienceOptions{
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+	})
+
+	// two failures should be hidden by the retries
+	flaky.failures.Store(2)
+	value, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	assert.Equal(t, int32(3), flaky.calls.Load())
+
+	// the third one should not
+	flaky.failures.Store(3)
+	_, err = cache.Get("a")
+	assert.Equal(t, errUnavailable, err)
+
+	// non-transient errors should not be retried
+	flaky.calls.Store(0)
+	_, err = cache.Get("missing")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, int32(1), flaky.calls.Load())
+
+	// neither should failures that a retry can't fix
+	flaky.err = &Error{Op: "Get", Backend: "test", Kind: ErrValueTooLarge}
+	flaky.calls.Store(0)
+	flaky.failures.Store(1)
+	_, err = cache.Get("a")
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+	assert.Equal(t, int32(1), flaky.calls.Load())
+}
+
+func TestResilientCacheTimeout(t *testing.T) {
+	slow := &flakyCache{Cache: inmem.NewInMemCache(), delay: 100 * time.Millisecond}
+	defer slow.Close()
+
+	cache := NewResilientCache(slow, ResilienceOptions{Timeout: 10 * time.Millisecond})
+	_, err := cache.Get("a")
+	assert.Equal(t, ErrTimeout, err)
+}
+
+func TestResilientCacheCircuitBreaker(t *testing.T) {
+	flaky := &flakyCache{Cache: inmem.NewInMemCache()}
+	defer flaky.Close()
+	assert.NoError(t, flaky.Set("a", "val_a", 0))
+
+	cache := NewResilientCache(flaky, ResilienceOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})
+
+	// breaker should open after two failures and fail fast
+	flaky.failures.Store(3)
+	for i := 0; i < 2; i++ {
+		_, err := cache.Get("a")
+		assert.Equal(t, errUnavailable, err)
+	}
+	_, err := cache.Get("a")
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, int32(2), flaky.calls.Load())
+
+	// failing trial call should open the breaker again
+	time.Sleep(60 * time.Millisecond)
+	_, err = cache.Get("a")
+	assert.Equal(t, errUnavailable, err)
+	_, err = cache.Get("a")
+	assert.Equal(t, ErrCircuitOpen, err)
+
+	// successful trial call should close it
+	time.Sleep(60 * time.Millisecond)
+	value, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+	value, err = cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "val_a", value)
+}
+
+func TestResilientCacheFallback(t *testing.T) {
+	fallback := inmem.NewInMemCache()
+	defer fallback.Close()
+
+	cache := NewResilientCache(unavailableCache{}, ResilienceOptions{
+		FailureThreshold: 1,
+		Fallback:         fallback,
+	})
+	testutil.TestBasic(t, cache)
+
+	value, err := fallback.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", value)
+
+	// the fallback is closed along with the cache
+	assert.NoError(t, cache.Close())
+	_, err = fallback.Get("key")
+	assert.ErrorIs(t, err, ErrCacheClosed)
+}
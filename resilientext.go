@@ -0,0 +1,313 @@
+package razcache
+
+import (
+	"time"
+)
+
+type resilientExtCache struct {
+	cache ExtendedCache
+	r     resilience
+}
+
+func NewResilientExtendedCache(cache ExtendedCache, opts ResilienceOptions) ExtendedCache {
+	c := &resilientExtCache{cache: cache}
+	c.r.init(opts)
+	return c
+}
+
+func (c *resilientExtCache) fallback() ExtendedCache {
+	fb, _ := c.r.opts.Fallback.(ExtendedCache)
+	return fb
+}
+
+func (c *resilientExtCache) Set(key, value string, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.Set(key, value, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.Set(key, value, ttl)
+	}, fallback)
+}
+
+func (c *resilientExtCache) Get(key string) (string, error) {
+	var fallback func() (string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (string, error) { return fb.Get(key) }
+	}
+	return resilientCall(&c.r, true, func() (string, error) {
+		return c.cache.Get(key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) Del(key string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.Del(key) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.Del(key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) GetTTL(key string) (time.Duration, error) {
+	var fallback func() (time.Duration, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (time.Duration, error) { return fb.GetTTL(key) }
+	}
+	return resilientCall(&c.r, true, func() (time.Duration, error) {
+		return c.cache.GetTTL(key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SetTTL(key string, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.SetTTL(key, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.SetTTL(key, ttl)
+	}, fallback)
+}
+
+func (c *resilientExtCache) LPush(key string, values ...string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.LPush(key, values...) }
+	}
+	return resilientExec(&c.r, false, func() error {
+		return c.cache.LPush(key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) RPush(key string, values ...string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.RPush(key, values...) }
+	}
+	return resilientExec(&c.r, false, func() error {
+		return c.cache.RPush(key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) LPop(key string, count int) ([]string, error) {
+	var fallback func() ([]string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]string, error) { return fb.LPop(key, count) }
+	}
+	return resilientCall(&c.r, false, func() ([]string, error) {
+		return c.cache.LPop(key, count)
+	}, fallback)
+}
+
+func (c *resilientExtCache) RPop(key string, count int) ([]string, error) {
+	var fallback func() ([]string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]string, error) { return fb.RPop(key, count) }
+	}
+	return resilientCall(&c.r, false, func() ([]string, error) {
+		return c.cache.RPop(key, count)
+	}, fallback)
+}
+
+func (c *resilientExtCache) LLen(key string) (int, error) {
+	var fallback func() (int, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (int, error) { return fb.LLen(key) }
+	}
+	return resilientCall(&c.r, true, func() (int, error) {
+		return c.cache.LLen(key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) LRange(key string, start, stop int) ([]string, error) {
+	var fallback func() ([]string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]string, error) { return fb.LRange(key, start, stop) }
+	}
+	return resilientCall(&c.r, true, func() ([]string, error) {
+		return c.cache.LRange(key, start, stop)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SAdd(key string, values ...string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.SAdd(key, values...) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.SAdd(key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SRem(key string, values ...string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return fb.SRem(key, values...) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return c.cache.SRem(key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SAddCount(key string, values ...string) (int, error) {
+	var fallback func() (int, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (int, error) { return SAddCount(fb, key, values...) }
+	}
+	return resilientCall(&c.r, true, func() (int, error) {
+		return SAddCount(c.cache, key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SRemCount(key string, values ...string) (int, error) {
+	var fallback func() (int, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (int, error) { return SRemCount(fb, key, values...) }
+	}
+	return resilientCall(&c.r, true, func() (int, error) {
+		return SRemCount(c.cache, key, values...)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SHas(key, value string) (bool, error) {
+	var fallback func() (bool, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (bool, error) { return fb.SHas(key, value) }
+	}
+	return resilientCall(&c.r, true, func() (bool, error) {
+		return c.cache.SHas(key, value)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SLen(key string) (int, error) {
+	var fallback func() (int, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (int, error) { return fb.SLen(key) }
+	}
+	return resilientCall(&c.r, true, func() (int, error) {
+		return c.cache.SLen(key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SMembers(key string) ([]string, error) {
+	var fallback func() ([]string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]string, error) { return SMembers(fb, key) }
+	}
+	return resilientCall(&c.r, true, func() ([]string, error) {
+		return SMembers(c.cache, key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) Incr(key string, increment int64) (int64, error) {
+	var fallback func() (int64, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (int64, error) { return fb.Incr(key, increment) }
+	}
+	return resilientCall(&c.r, false, func() (int64, error) {
+		return c.cache.Incr(key, increment)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return SetBytes(fb, key, value, ttl) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return SetBytes(c.cache, key, value, ttl)
+	}, fallback)
+}
+
+func (c *resilientExtCache) GetBytes(key string) ([]byte, error) {
+	var fallback func() ([]byte, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() ([]byte, error) { return GetBytes(fb, key) }
+	}
+	return resilientCall(&c.r, true, func() ([]byte, error) {
+		return GetBytes(c.cache, key)
+	}, fallback)
+}
+
+// AppendBytes doesn't pass dst to the underlying cache, as calls abandoned
+// after a timeout could still write into it
+func (c *resilientExtCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	value, err := c.GetBytes(key)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, value...), nil
+}
+
+func (c *resilientExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	var fallback func() (string, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (string, error) { return GetEx(fb, key, ttl) }
+	}
+	return resilientCall(&c.r, true, func() (string, error) {
+		return GetEx(c.cache, key, ttl)
+	}, fallback)
+}
+
+func (c *resilientExtCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	var fallback func() (bool, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (bool, error) { return Expire(fb, key, ttl, cond) }
+	}
+	return resilientCall(&c.r, true, func() (bool, error) {
+		return Expire(c.cache, key, ttl, cond)
+	}, fallback)
+}
+
+func (c *resilientExtCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	var fallback func() (bool, error)
+	if fb := c.fallback(); fb != nil {
+		fallback = func() (bool, error) { return ExpireAt(fb, key, deadline, cond) }
+	}
+	return resilientCall(&c.r, true, func() (bool, error) {
+		return ExpireAt(c.cache, key, deadline, cond)
+	}, fallback)
+}
+
+func (c *resilientExtCache) Persist(key string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return Persist(fb, key) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return Persist(c.cache, key)
+	}, fallback)
+}
+
+func (c *resilientExtCache) SetKeepTTL(key, value string) error {
+	var fallback func() error
+	if fb := c.fallback(); fb != nil {
+		fallback = func() error { return SetKeepTTL(fb, key, value) }
+	}
+	return resilientExec(&c.r, true, func() error {
+		return SetKeepTTL(c.cache, key, value)
+	}, fallback)
+}
+
+// Scan is forwarded without retries and timeout, so fn never sees a key twice
+// or gets called after Scan returned
+func (c *resilientExtCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.cache, prefix, fn)
+}
+
+func (c *resilientExtCache) Capabilities() Capability {
+	return Capabilities(c.cache)
+}
+
+func (c *resilientExtCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *resilientExtCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *resilientExtCache) Close() error {
+	return c.r.close(c.cache)
+}
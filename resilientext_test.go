@@ -0,0 +1,25 @@
+package razcache_test
+
+import (
+	"testing"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestResilientExtendedCache(t *testing.T) {
+	cache := NewResilientExtendedCache(inmem.NewInMemExtendedCache(), ResilienceOptions{MaxRetries: 2})
+	defer cache.Close()
+
+	testutil.TestBasic(t, cache)
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+	testutil.TestIncr(t, cache)
+}
+
+func TestResilientExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		return NewResilientExtendedCache(inmem.NewInMemExtendedCache(), ResilienceOptions{MaxRetries: 2})
+	})
+}
@@ -0,0 +1,37 @@
+package razcache
+
+import (
+	"strings"
+)
+
+// Scanner is implemented by caches that can iterate over their keys.
+// Scan calls fn for each key starting with prefix until fn returns false.
+// The order of keys is unspecified.
+type Scanner interface {
+	Scan(prefix string, fn func(key string) bool) error
+}
+
+// Scan iterates over the keys of the cache if it implements Scanner,
+// otherwise it returns ErrNotSupported
+func Scan(cache Cache, prefix string, fn func(key string) bool) error {
+	if scanner, ok := cache.(Scanner); ok {
+		return scanner.Scan(prefix, fn)
+	}
+	return ErrNotSupported
+}
+
+// ScanKeys collects the keys starting with prefix
+func ScanKeys(cache Cache, prefix string) ([]string, error) {
+	var keys []string
+	err := Scan(cache, prefix, func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys, err
+}
+
+func scanPrefixed(cache Cache, cachePrefix, prefix string, fn func(key string) bool) error {
+	return Scan(cache, cachePrefix+prefix, func(key string) bool {
+		return fn(strings.TrimPrefix(key, cachePrefix))
+	})
+}
@@ -0,0 +1,49 @@
+package razcache
+
+// SetCounter is implemented by extended caches that can tell how many members SAdd and SRem
+// changed at once, like the Redis SADD and SREM commands
+type SetCounter interface {
+	SAddCount(key string, values ...string) (int, error)
+	SRemCount(key string, values ...string) (int, error)
+}
+
+// SAddCount adds the members to the set and returns how many of them weren't members yet.
+// Caches that don't implement SetCounter fall back to comparing SLen before and after SAdd,
+// which counts concurrent changes of the set too.
+func SAddCount(cache ExtendedCache, key string, values ...string) (int, error) {
+	if counter, ok := cache.(SetCounter); ok {
+		return counter.SAddCount(key, values...)
+	}
+	added, err := setLenChange(cache, key, func() error {
+		return cache.SAdd(key, values...)
+	})
+	return max(added, 0), err
+}
+
+// SRemCount removes the members from the set and returns how many of them were members.
+// Caches that don't implement SetCounter fall back to comparing SLen before and after SRem,
+// which counts concurrent changes of the set too.
+func SRemCount(cache ExtendedCache, key string, values ...string) (int, error) {
+	if counter, ok := cache.(SetCounter); ok {
+		return counter.SRemCount(key, values...)
+	}
+	removed, err := setLenChange(cache, key, func() error {
+		return cache.SRem(key, values...)
+	})
+	return max(-removed, 0), err
+}
+
+func setLenChange(cache ExtendedCache, key string, update func() error) (int, error) {
+	before, err := cache.SLen(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := update(); err != nil {
+		return 0, err
+	}
+	after, err := cache.SLen(key)
+	if err != nil {
+		return 0, err
+	}
+	return after - before, nil
+}
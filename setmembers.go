@@ -0,0 +1,16 @@
+package razcache
+
+// SetMemberLister is implemented by extended caches that can list the members of a set.
+// The order of members is unspecified, missing keys are empty sets.
+type SetMemberLister interface {
+	SMembers(key string) ([]string, error)
+}
+
+// SMembers lists the members of a set if the cache implements SetMemberLister,
+// otherwise it returns ErrNotSupported
+func SMembers(cache Cache, key string) ([]string, error) {
+	if lister, ok := cache.(SetMemberLister); ok {
+		return lister.SMembers(key)
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,178 @@
+package razcache
+
+import (
+	"errors"
+	"time"
+)
+
+// ShardedCache spreads keys over multiple caches using consistent hashing
+type ShardedCache struct {
+	ring hashRing[Cache]
+}
+
+// NewShardedCache creates an empty sharded cache where every unit of shard
+// weight is represented by virtualNodes points on the hash ring
+func NewShardedCache(virtualNodes int) *ShardedCache {
+	c := new(ShardedCache)
+	c.ring.init(virtualNodes)
+	return c
+}
+
+func (c *ShardedCache) AddShard(name string, cache Cache, weight int) error {
+	return c.ring.add(name, cache, weight)
+}
+
+// RemoveShard removes the shard from the ring without closing it
+func (c *ShardedCache) RemoveShard(name string) (Cache, bool) {
+	return c.ring.remove(name)
+}
+
+// ShardFor returns the name of the shard the key belongs to
+func (c *ShardedCache) ShardFor(key string) (string, error) {
+	name, _, err := c.ring.get(key)
+	return name, err
+}
+
+func (c *ShardedCache) Set(key, value string, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.Set(key, value, ttl)
+}
+
+func (c *ShardedCache) Get(key string) (string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.Get(key)
+}
+
+func (c *ShardedCache) Del(key string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.Del(key)
+}
+
+func (c *ShardedCache) GetTTL(key string) (time.Duration, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.GetTTL(key)
+}
+
+func (c *ShardedCache) SetTTL(key string, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.SetTTL(key, ttl)
+}
+
+func (c *ShardedCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return SetBytes(shard, key, value, ttl)
+}
+
+func (c *ShardedCache) GetBytes(key string) ([]byte, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return GetBytes(shard, key)
+}
+
+func (c *ShardedCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return dst, err
+	}
+	return AppendBytes(shard, dst, key)
+}
+
+func (c *ShardedCache) GetEx(key string, ttl time.Duration) (string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return "", err
+	}
+	return GetEx(shard, key, ttl)
+}
+
+func (c *ShardedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return false, err
+	}
+	return Expire(shard, key, ttl, cond)
+}
+
+func (c *ShardedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return false, err
+	}
+	return ExpireAt(shard, key, deadline, cond)
+}
+
+func (c *ShardedCache) Persist(key string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return Persist(shard, key)
+}
+
+func (c *ShardedCache) SetKeepTTL(key, value string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return SetKeepTTL(shard, key, value)
+}
+
+// Scan scans the shards one after the other
+func (c *ShardedCache) Scan(prefix string, fn func(key string) bool) error {
+	return scanShards(c.ring.all(), prefix, fn)
+}
+
+// Capabilities only includes the ones all shards have
+func (c *ShardedCache) Capabilities() Capability {
+	return commonCapabilities(c.ring.all()...) & capOptional
+}
+
+func (c *ShardedCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+// Close closes all shards
+func (c *ShardedCache) Close() error {
+	var errs []error
+	for _, shard := range c.ring.all() {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func scanShards[T Cache](shards []T, prefix string, fn func(key string) bool) error {
+	if len(shards) == 0 {
+		return ErrNoShards
+	}
+	stopped := false
+	for _, shard := range shards {
+		err := Scan(shard, prefix, func(key string) bool {
+			stopped = !fn(key)
+			return !stopped
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package razcache_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestShardedCache(t *testing.T) {
+	cache := NewShardedCache(0)
+	defer cache.Close()
+
+	_, err := cache.Get("key")
+	assert.Equal(t, ErrNoShards, err)
+
+	shards := make(map[string]Cache)
+	for i := 0; i < 3; i++ {
+		name := "shard" + strconv.Itoa(i)
+		shards[name] = inmem.NewInMemCache()
+		assert.NoError(t, cache.AddShard(name, shards[name], 1))
+	}
+	assert.Error(t, cache.AddShard("shard0", inmem.NewInMemCache(), 1))
+
+	testutil.TestBasic(t, cache)
+
+	// keys should be stored in the shard they are routed to
+	for i := 0; i < 100; i++ {
+		key := "key" + strconv.Itoa(i)
+		assert.NoError(t, cache.Set(key, "value", 0))
+		name, err := cache.ShardFor(key)
+		assert.NoError(t, err)
+		value, err := shards[name].Get(key)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	}
+}
+
+func TestShardedCacheRebalance(t *testing.T) {
+	cache := NewShardedCache(0)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, cache.AddShard("shard"+strconv.Itoa(i), inmem.NewInMemCache(), 1))
+	}
+
+	const keyCount = 10000
+	owners := make([]string, keyCount)
+	for i := range owners {
+		owners[i], _ = cache.ShardFor("key" + strconv.Itoa(i))
+	}
+
+	// adding a shard should only move keys to the new shard
+	newShard := inmem.NewInMemCache()
+	assert.NoError(t, cache.AddShard("shard3", newShard, 1))
+	moved := 0
+	for i := range owners {
+		owner, _ := cache.ShardFor("key" + strconv.Itoa(i))
+		if owner != owners[i] {
+			assert.Equal(t, "shard3", owner)
+			moved++
+		}
+	}
+	assert.InDelta(t, keyCount/4, moved, keyCount/10)
+
+	// removing it should restore the original distribution
+	removed, ok := cache.RemoveShard("shard3")
+	assert.True(t, ok)
+	assert.Equal(t, newShard, removed)
+	for i := range owners {
+		owner, _ := cache.ShardFor("key" + strconv.Itoa(i))
+		assert.Equal(t, owners[i], owner)
+	}
+	_, ok = cache.RemoveShard("shard3")
+	assert.False(t, ok)
+}
+
+func TestShardedCacheWeights(t *testing.T) {
+	cache := NewShardedCache(0)
+	assert.NoError(t, cache.AddShard("light", inmem.NewInMemCache(), 1))
+	assert.NoError(t, cache.AddShard("heavy", inmem.NewInMemCache(), 3))
+	assert.Error(t, cache.AddShard("invalid", inmem.NewInMemCache(), 0))
+
+	const keyCount = 10000
+	heavy := 0
+	for i := 0; i < keyCount; i++ {
+		if owner, _ := cache.ShardFor("key" + strconv.Itoa(i)); owner == "heavy" {
+			heavy++
+		}
+	}
+	assert.InDelta(t, keyCount*3/4, heavy, keyCount/10)
+}
@@ -0,0 +1,272 @@
+package razcache
+
+import (
+	"errors"
+	"time"
+)
+
+// ShardedExtendedCache is the ExtendedCache counterpart of ShardedCache
+type ShardedExtendedCache struct {
+	ring hashRing[ExtendedCache]
+}
+
+func NewShardedExtendedCache(virtualNodes int) *ShardedExtendedCache {
+	c := new(ShardedExtendedCache)
+	c.ring.init(virtualNodes)
+	return c
+}
+
+func (c *ShardedExtendedCache) AddShard(name string, cache ExtendedCache, weight int) error {
+	return c.ring.add(name, cache, weight)
+}
+
+func (c *ShardedExtendedCache) RemoveShard(name string) (ExtendedCache, bool) {
+	return c.ring.remove(name)
+}
+
+func (c *ShardedExtendedCache) ShardFor(key string) (string, error) {
+	name, _, err := c.ring.get(key)
+	return name, err
+}
+
+func (c *ShardedExtendedCache) Set(key, value string, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.Set(key, value, ttl)
+}
+
+func (c *ShardedExtendedCache) Get(key string) (string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.Get(key)
+}
+
+func (c *ShardedExtendedCache) Del(key string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.Del(key)
+}
+
+func (c *ShardedExtendedCache) GetTTL(key string) (time.Duration, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.GetTTL(key)
+}
+
+func (c *ShardedExtendedCache) SetTTL(key string, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.SetTTL(key, ttl)
+}
+
+func (c *ShardedExtendedCache) LPush(key string, values ...string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.LPush(key, values...)
+}
+
+func (c *ShardedExtendedCache) RPush(key string, values ...string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.RPush(key, values...)
+}
+
+func (c *ShardedExtendedCache) LPop(key string, count int) ([]string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.LPop(key, count)
+}
+
+func (c *ShardedExtendedCache) RPop(key string, count int) ([]string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.RPop(key, count)
+}
+
+func (c *ShardedExtendedCache) LLen(key string) (int, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.LLen(key)
+}
+
+func (c *ShardedExtendedCache) LRange(key string, start, stop int) ([]string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.LRange(key, start, stop)
+}
+
+func (c *ShardedExtendedCache) SAdd(key string, values ...string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.SAdd(key, values...)
+}
+
+func (c *ShardedExtendedCache) SRem(key string, values ...string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return shard.SRem(key, values...)
+}
+
+func (c *ShardedExtendedCache) SAddCount(key string, values ...string) (int, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return SAddCount(shard, key, values...)
+}
+
+func (c *ShardedExtendedCache) SRemCount(key string, values ...string) (int, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return SRemCount(shard, key, values...)
+}
+
+func (c *ShardedExtendedCache) SHas(key, value string) (bool, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return false, err
+	}
+	return shard.SHas(key, value)
+}
+
+func (c *ShardedExtendedCache) SLen(key string) (int, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.SLen(key)
+}
+
+func (c *ShardedExtendedCache) SMembers(key string) ([]string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return SMembers(shard, key)
+}
+
+func (c *ShardedExtendedCache) Incr(key string, increment int64) (int64, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.Incr(key, increment)
+}
+
+func (c *ShardedExtendedCache) SetBytes(key string, value []byte, ttl time.Duration) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return SetBytes(shard, key, value, ttl)
+}
+
+func (c *ShardedExtendedCache) GetBytes(key string) ([]byte, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return GetBytes(shard, key)
+}
+
+func (c *ShardedExtendedCache) AppendBytes(dst []byte, key string) ([]byte, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return dst, err
+	}
+	return AppendBytes(shard, dst, key)
+}
+
+func (c *ShardedExtendedCache) GetEx(key string, ttl time.Duration) (string, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return "", err
+	}
+	return GetEx(shard, key, ttl)
+}
+
+func (c *ShardedExtendedCache) Expire(key string, ttl time.Duration, cond ExpireCondition) (bool, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return false, err
+	}
+	return Expire(shard, key, ttl, cond)
+}
+
+func (c *ShardedExtendedCache) ExpireAt(key string, deadline time.Time, cond ExpireCondition) (bool, error) {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return false, err
+	}
+	return ExpireAt(shard, key, deadline, cond)
+}
+
+func (c *ShardedExtendedCache) Persist(key string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return Persist(shard, key)
+}
+
+func (c *ShardedExtendedCache) SetKeepTTL(key, value string) error {
+	_, shard, err := c.ring.get(key)
+	if err != nil {
+		return err
+	}
+	return SetKeepTTL(shard, key, value)
+}
+
+// Scan scans the shards one after the other
+func (c *ShardedExtendedCache) Scan(prefix string, fn func(key string) bool) error {
+	return scanShards(c.ring.all(), prefix, fn)
+}
+
+// Capabilities only includes the ones all shards have
+func (c *ShardedExtendedCache) Capabilities() Capability {
+	return commonCapabilities(c.ring.all()...)
+}
+
+func (c *ShardedExtendedCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *ShardedExtendedCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *ShardedExtendedCache) Close() error {
+	var errs []error
+	for _, shard := range c.ring.all() {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
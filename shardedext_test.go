@@ -0,0 +1,37 @@
+package razcache_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestShardedExtendedCache(t *testing.T) {
+	cache := NewShardedExtendedCache(0)
+	defer cache.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, cache.AddShard("shard"+strconv.Itoa(i), inmem.NewInMemExtendedCache(), 1))
+	}
+
+	testutil.TestBasic(t, cache)
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+	testutil.TestIncr(t, cache)
+}
+
+func TestShardedExtendedCacheConformance(t *testing.T) {
+	testutil.RunConformance(t, func(t *testing.T) Cache {
+		cache := NewShardedExtendedCache(0)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cache.AddShard("shard"+strconv.Itoa(i), inmem.NewInMemExtendedCache(), 1))
+		}
+		return cache
+	})
+}
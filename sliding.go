@@ -0,0 +1,64 @@
+package razcache
+
+import (
+	"time"
+)
+
+type slidingCache struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// NewSlidingCache restarts the TTL of keys whenever they are read, and uses it
+// for keys that are set without TTL. Keys set with a TTL keep it until they are read.
+// Sub caches are created on top of the returned cache, so they keep sliding.
+func NewSlidingCache(cache Cache, ttl time.Duration) Cache {
+	return &slidingCache{
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+func (c *slidingCache) Set(key, value string, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	return c.cache.Set(key, value, ttl)
+}
+
+func (c *slidingCache) Get(key string) (string, error) {
+	return GetEx(c.cache, key, c.ttl)
+}
+
+func (c *slidingCache) GetEx(key string, ttl time.Duration) (string, error) {
+	return GetEx(c.cache, key, ttl)
+}
+
+func (c *slidingCache) Del(key string) error {
+	return c.cache.Del(key)
+}
+
+func (c *slidingCache) GetTTL(key string) (time.Duration, error) {
+	return c.cache.GetTTL(key)
+}
+
+func (c *slidingCache) SetTTL(key string, ttl time.Duration) error {
+	return c.cache.SetTTL(key, ttl)
+}
+
+func (c *slidingCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.cache, prefix, fn)
+}
+
+// Capabilities doesn't include the ones the sliding cache doesn't forward
+func (c *slidingCache) Capabilities() Capability {
+	return Capabilities(c.cache) & (CapScan | CapGetEx)
+}
+
+func (c *slidingCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *slidingCache) Close() error {
+	return c.cache.Close()
+}
@@ -0,0 +1,73 @@
+package razcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestSlidingCache(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewSlidingCache(inmem.NewInMemCache(inmem.WithClock(clock)), time.Minute)
+	defer cache.Close()
+
+	// keys set without TTL get the sliding TTL
+	assert.NoError(t, cache.Set("key", "value", 0))
+	ttl, err := cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// reads restart the TTL
+	for i := 0; i < 3; i++ {
+		clock.Advance(40 * time.Second)
+		value, err := cache.Get("key")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	}
+	clock.Advance(time.Minute)
+	_, err = cache.Get("key")
+	assert.Equal(t, ErrNotFound, err)
+
+	// explicit TTLs are kept until the first read
+	assert.NoError(t, cache.Set("key", "value", time.Hour))
+	ttl, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, ttl)
+	_, err = cache.Get("key")
+	assert.NoError(t, err)
+	ttl, err = cache.GetTTL("key")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// sub caches keep sliding
+	sub := cache.SubCache("sub:")
+	assert.NoError(t, sub.Set("key", "value", 0))
+	clock.Advance(40 * time.Second)
+	_, err = sub.Get("key")
+	assert.NoError(t, err)
+	clock.Advance(40 * time.Second)
+	_, err = sub.Get("key")
+	assert.NoError(t, err)
+}
+
+func TestSlidingSubCache(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := inmem.NewInMemCache(inmem.WithClock(clock))
+	defer cache.Close()
+	sessions := NewSlidingCache(cache.SubCache("session:"), time.Minute)
+
+	// only the wrapped sub cache slides
+	assert.NoError(t, sessions.Set("id", "user", 0))
+	assert.NoError(t, cache.Set("other", "value", 0))
+	ttl, err := cache.GetTTL("session:id")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+	ttl, err = cache.GetTTL("other")
+	assert.NoError(t, err)
+	assert.Equal(t, NoExpiry, ttl)
+}
@@ -0,0 +1,145 @@
+package razcache
+
+import (
+	"errors"
+	"time"
+)
+
+type slidingExtCache struct {
+	cache ExtendedCache
+	ttl   time.Duration
+}
+
+// NewSlidingExtendedCache is like NewSlidingCache, but lists, sets and counters
+// get their TTL restarted after every access, which takes an extra SetTTL call
+func NewSlidingExtendedCache(cache ExtendedCache, ttl time.Duration) ExtendedCache {
+	return &slidingExtCache{
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+// touch restarts the TTL of the key if the operation succeeded and the key still exists
+func (c *slidingExtCache) touch(key string, err error) error {
+	if err != nil {
+		return err
+	}
+	if err := c.cache.SetTTL(key, c.ttl); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (c *slidingExtCache) Set(key, value string, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	return c.cache.Set(key, value, ttl)
+}
+
+func (c *slidingExtCache) Get(key string) (string, error) {
+	return GetEx(c.cache, key, c.ttl)
+}
+
+func (c *slidingExtCache) GetEx(key string, ttl time.Duration) (string, error) {
+	return GetEx(c.cache, key, ttl)
+}
+
+func (c *slidingExtCache) Del(key string) error {
+	return c.cache.Del(key)
+}
+
+func (c *slidingExtCache) GetTTL(key string) (time.Duration, error) {
+	return c.cache.GetTTL(key)
+}
+
+func (c *slidingExtCache) SetTTL(key string, ttl time.Duration) error {
+	return c.cache.SetTTL(key, ttl)
+}
+
+func (c *slidingExtCache) LPush(key string, values ...string) error {
+	return c.touch(key, c.cache.LPush(key, values...))
+}
+
+func (c *slidingExtCache) RPush(key string, values ...string) error {
+	return c.touch(key, c.cache.RPush(key, values...))
+}
+
+func (c *slidingExtCache) LPop(key string, count int) ([]string, error) {
+	values, err := c.cache.LPop(key, count)
+	return values, c.touch(key, err)
+}
+
+func (c *slidingExtCache) RPop(key string, count int) ([]string, error) {
+	values, err := c.cache.RPop(key, count)
+	return values, c.touch(key, err)
+}
+
+func (c *slidingExtCache) LLen(key string) (int, error) {
+	length, err := c.cache.LLen(key)
+	return length, c.touch(key, err)
+}
+
+func (c *slidingExtCache) LRange(key string, start, stop int) ([]string, error) {
+	values, err := c.cache.LRange(key, start, stop)
+	return values, c.touch(key, err)
+}
+
+func (c *slidingExtCache) SAdd(key string, values ...string) error {
+	return c.touch(key, c.cache.SAdd(key, values...))
+}
+
+func (c *slidingExtCache) SRem(key string, values ...string) error {
+	return c.touch(key, c.cache.SRem(key, values...))
+}
+
+func (c *slidingExtCache) SAddCount(key string, values ...string) (int, error) {
+	added, err := SAddCount(c.cache, key, values...)
+	return added, c.touch(key, err)
+}
+
+func (c *slidingExtCache) SRemCount(key string, values ...string) (int, error) {
+	removed, err := SRemCount(c.cache, key, values...)
+	return removed, c.touch(key, err)
+}
+
+func (c *slidingExtCache) SHas(key, value string) (bool, error) {
+	has, err := c.cache.SHas(key, value)
+	return has, c.touch(key, err)
+}
+
+func (c *slidingExtCache) SLen(key string) (int, error) {
+	length, err := c.cache.SLen(key)
+	return length, c.touch(key, err)
+}
+
+func (c *slidingExtCache) SMembers(key string) ([]string, error) {
+	members, err := SMembers(c.cache, key)
+	return members, c.touch(key, err)
+}
+
+func (c *slidingExtCache) Incr(key string, increment int64) (int64, error) {
+	value, err := c.cache.Incr(key, increment)
+	return value, c.touch(key, err)
+}
+
+func (c *slidingExtCache) Scan(prefix string, fn func(key string) bool) error {
+	return Scan(c.cache, prefix, fn)
+}
+
+// Capabilities doesn't include the ones the sliding cache doesn't forward
+func (c *slidingExtCache) Capabilities() Capability {
+	return Capabilities(c.cache) & (CapExtended | CapSetMembers | CapSetCount | CapScan | CapGetEx)
+}
+
+func (c *slidingExtCache) SubCache(prefix string) Cache {
+	return NewPrefixCache(c, prefix)
+}
+
+func (c *slidingExtCache) SubExtendedCache(prefix string) ExtendedCache {
+	return NewPrefixExtendedCache(c, prefix)
+}
+
+func (c *slidingExtCache) Close() error {
+	return c.cache.Close()
+}
@@ -0,0 +1,50 @@
+package razcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/razzie/razcache"
+	"github.com/razzie/razcache/pkg/inmem"
+	"github.com/razzie/razcache/pkg/testutil"
+)
+
+func TestSlidingExtendedCache(t *testing.T) {
+	clock := testutil.NewFakeClock(time.Now())
+	cache := NewSlidingExtendedCache(inmem.NewInMemExtendedCache(inmem.WithClock(clock)), time.Minute)
+	defer cache.Close()
+
+	testutil.TestLists(t, cache)
+	testutil.TestSets(t, cache)
+
+	// collections get the sliding TTL when they are written, and it restarts when they are read
+	assert.NoError(t, cache.RPush("list", "a", "b"))
+	assert.NoError(t, cache.SAdd("set", "a"))
+	_, err := cache.Incr("counter", 1)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		clock.Advance(40 * time.Second)
+		values, err := cache.LRange("list", 0, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, values)
+		has, err := cache.SHas("set", "a")
+		assert.NoError(t, err)
+		assert.True(t, has)
+		value, err := cache.Get("counter")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", value)
+	}
+	clock.Advance(time.Minute)
+	for _, key := range []string{"list", "set", "counter"} {
+		_, err := cache.GetTTL(key)
+		assert.Equal(t, ErrNotFound, err, key)
+	}
+
+	// popping the last value doesn't fail on the missing key
+	assert.NoError(t, cache.RPush("list", "a"))
+	values, err := cache.LPop("list", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, values)
+}
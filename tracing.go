@@ -0,0 +1,32 @@
+package razcache
+
+import "errors"
+
+// Tracer creates spans, so tracing libraries (e.g. OpenTelemetry)
+// can be connected to TracingMiddleware with a thin adapter
+type Tracer interface {
+	Start(name string) Span
+}
+
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// TracingMiddleware wraps every operation in a span named "razcache.<op>"
+func TracingMiddleware(tracer Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation) {
+			span := tracer.Start("razcache." + op.Name)
+			defer span.End()
+			if len(op.Keys) > 0 {
+				span.SetAttribute("razcache.keys", op.Keys)
+			}
+			next(op)
+			if op.Err != nil && !errors.Is(op.Err, ErrNotFound) {
+				span.RecordError(op.Err)
+			}
+		}
+	}
+}